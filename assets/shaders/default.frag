@@ -1,16 +1,124 @@
 #version 460
+
+#include <noor/lights.glsl>
+
 out vec4 fragColor;
 
 in vec3 vColor;
 in vec2 vUv;
 in vec3 vNormal;
+in vec3 vWorldPos;
 
+// textures added with Object.AddTexture
 uniform sampler2D uTexture;
 
-void main() {
+// material texture slots
+uniform sampler2D uDiffuseMap;
+uniform sampler2D uSpecularMap;
+uniform sampler2D uNormalMap;
+uniform sampler2D uEmissiveMap;
+uniform bool uHasDiffuseMap;
+uniform bool uHasSpecularMap;
+uniform bool uHasNormalMap;
+uniform bool uHasEmissiveMap;
+
+// material parameters
+uniform vec4 uDiffuseColor = vec4(1.0);
+uniform vec4 uSpecularColor = vec4(0.5, 0.5, 0.5, 1.0);
+uniform vec4 uEmissiveColor = vec4(0.0, 0.0, 0.0, 1.0);
+uniform float uShininess = 32.0;
+
+vec3 baseColor() {
+  if (uHasDiffuseMap) {
+    return texture(uDiffuseMap, vUv).rgb * uDiffuseColor.rgb;
+  }
+
   vec4 texColor = texture(uTexture, vUv);
   float r = texColor.r * int(texColor.r != 0) + vColor.r * int(texColor.r == 0);
   float g = texColor.g * int(texColor.g != 0) + vColor.g * int(texColor.g == 0);
   float b = texColor.b * int(texColor.b != 0) + vColor.b * int(texColor.b == 0);
-  fragColor = vec4(r, g, b, 1.0);
+  return vec3(r, g, b) * uDiffuseColor.rgb;
+}
+
+// perturbNormal applies the normal map using a tangent frame built from screen
+// space derivatives, since vertices carry no tangents.
+vec3 perturbNormal(vec3 normal) {
+  vec3 dp1 = dFdx(vWorldPos);
+  vec3 dp2 = dFdy(vWorldPos);
+  vec2 duv1 = dFdx(vUv);
+  vec2 duv2 = dFdy(vUv);
+
+  vec3 dp2perp = cross(dp2, normal);
+  vec3 dp1perp = cross(normal, dp1);
+  vec3 tangent = dp2perp * duv1.x + dp1perp * duv2.x;
+  vec3 bitangent = dp2perp * duv1.y + dp1perp * duv2.y;
+  float invmax = inversesqrt(max(dot(tangent, tangent), dot(bitangent, bitangent)));
+  mat3 tbn = mat3(tangent * invmax, bitangent * invmax, normal);
+
+  vec3 mapped = texture(uNormalMap, vUv).xyz * 2.0 - 1.0;
+  return normalize(tbn * mapped);
+}
+
+vec3 blinnPhong(Light light, vec3 normal, vec3 viewDir, vec3 albedo, vec3 specularColor) {
+  vec3 lightDir;
+  float attenuation = 1.0;
+
+  if (light.type == LIGHT_DIRECTIONAL) {
+    lightDir = normalize(-light.direction);
+  } else {
+    vec3 toLight = light.position - vWorldPos;
+    float dist = length(toLight);
+    lightDir = toLight / dist;
+    attenuation = 1.0 / (light.constant + light.linear * dist + light.quadratic * dist * dist);
+
+    if (light.type == LIGHT_SPOT) {
+      float theta = dot(lightDir, normalize(-light.direction));
+      float epsilon = max(light.innerCutoff - light.outerCutoff, 0.0001);
+      attenuation *= clamp((theta - light.outerCutoff) / epsilon, 0.0, 1.0);
+    }
+  }
+
+  float diffuse = max(dot(normal, lightDir), 0.0);
+  vec3 halfway = normalize(lightDir + viewDir);
+  float specular = diffuse > 0.0 ? pow(max(dot(normal, halfway), 0.0), uShininess) : 0.0;
+
+  vec3 radiance = light.color * light.intensity * attenuation;
+  return (albedo * diffuse + specularColor * specular) * radiance;
+}
+
+void main() {
+  vec3 albedo = baseColor();
+  float alpha = uDiffuseColor.a;
+
+  vec3 emissive = uEmissiveColor.rgb;
+  if (uHasEmissiveMap) {
+    emissive *= texture(uEmissiveMap, vUv).rgb;
+  }
+
+  // without lights the scene is shown unlit
+  if (uLightCount == 0) {
+    fragColor = vec4(albedo + emissive, alpha);
+    return;
+  }
+
+  vec3 normal = normalize(vNormal);
+  if (!gl_FrontFacing) {
+    normal = -normal;
+  }
+  vec3 viewDir = normalize(uViewPos - vWorldPos);
+  if (uHasNormalMap) {
+    normal = perturbNormal(normal);
+  }
+
+  vec3 specularColor = uSpecularColor.rgb;
+  if (uHasSpecularMap) {
+    specularColor *= texture(uSpecularMap, vUv).rgb;
+  }
+
+  vec3 color = uAmbient * albedo + emissive;
+  for (int i = 0; i < min(uLightCount, MAX_LIGHTS); i++) {
+    color += blinnPhong(uLights[i], normal, viewDir, albedo, specularColor);
+  }
+
+  fragColor = vec4(color, alpha);
 }
\ No newline at end of file
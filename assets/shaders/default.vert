@@ -1,21 +1,18 @@
 #version 460
 
-layout(location = 0) in vec3 aPosition;
-layout(location = 1) in vec3 aColor;
-layout(location = 2) in vec2 aUv;
-layout(location = 3) in vec3 aNormal;
+#include <noor/vertex.glsl>
 
 out vec3 vColor;
 out vec2 vUv;
 out vec3 vNormal;
-
-uniform mat4 uProjection;
-uniform mat4 uView;
-uniform mat4 uModel;
+out vec3 vWorldPos;
 
 void main() {
-  gl_Position = uProjection * uView * uModel * vec4(aPosition, 1.0);
-  vColor = aColor;
+  mat4 model = uInstanced ? uModel * aInstanceModel : uModel;
+  vec4 worldPos = model * vec4(aPosition, 1.0);
+  gl_Position = uProjection * uView * worldPos;
+  vColor = uInstanced ? aColor * aInstanceColor.rgb : aColor;
   vUv = aUv;
-  vNormal = aNormal;
-}
+  vNormal = mat3(transpose(inverse(model))) * aNormal;
+  vWorldPos = worldPos.xyz;
+}
\ No newline at end of file
@@ -0,0 +1,28 @@
+#pragma once
+
+#ifndef MAX_LIGHTS
+#define MAX_LIGHTS 8
+#endif
+
+#define LIGHT_DIRECTIONAL 0
+#define LIGHT_POINT 1
+#define LIGHT_SPOT 2
+
+// lights set by Scene.Render
+struct Light {
+  int type;
+  vec3 position;
+  vec3 direction;
+  vec3 color;
+  float intensity;
+  float constant;
+  float linear;
+  float quadratic;
+  float innerCutoff; // cosine of the inner cone angle
+  float outerCutoff; // cosine of the outer cone angle
+};
+
+uniform Light uLights[MAX_LIGHTS];
+uniform int uLightCount;
+uniform vec3 uAmbient;
+uniform vec3 uViewPos;
@@ -0,0 +1,17 @@
+#pragma once
+
+// vertex attribute layout of noor.Vertex
+layout(location = 0) in vec3 aPosition;
+layout(location = 1) in vec3 aColor;
+layout(location = 2) in vec2 aUv;
+layout(location = 3) in vec3 aNormal;
+
+// per instance data of instanced draws, see InstanceLayout
+layout(location = 4) in mat4 aInstanceModel;
+layout(location = 8) in vec4 aInstanceColor;
+
+// transforms set by Object.Render
+uniform mat4 uProjection;
+uniform mat4 uView;
+uniform mat4 uModel;
+uniform bool uInstanced;
@@ -0,0 +1,13 @@
+#version 460
+
+in vec2 vUv;
+out vec4 fragColor;
+
+uniform sampler2D uInput;
+uniform sampler2D uBloom;
+uniform float uIntensity;
+
+void main() {
+  vec4 color = texture(uInput, vUv);
+  fragColor = vec4(color.rgb + texture(uBloom, vUv).rgb * uIntensity, color.a);
+}
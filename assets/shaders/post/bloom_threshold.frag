@@ -0,0 +1,14 @@
+#version 460
+
+in vec2 vUv;
+out vec4 fragColor;
+
+uniform sampler2D uInput;
+uniform float uThreshold;
+
+void main() {
+  vec3 color = texture(uInput, vUv).rgb;
+  float brightness = max(color.r, max(color.g, color.b));
+  float contribution = max(brightness - uThreshold, 0.0) / max(brightness, 1e-4);
+  fragColor = vec4(color * contribution, 1.0);
+}
@@ -0,0 +1,18 @@
+#version 460
+
+in vec2 vUv;
+out vec4 fragColor;
+
+uniform sampler2D uInput;
+uniform vec2 uDirection; // uv step between taps along the blur axis
+
+const float weights[5] = float[](0.227027, 0.1945946, 0.1216216, 0.054054, 0.016216);
+
+void main() {
+  vec4 sum = texture(uInput, vUv) * weights[0];
+  for (int i = 1; i < 5; i++) {
+    vec2 offset = uDirection * float(i);
+    sum += (texture(uInput, vUv + offset) + texture(uInput, vUv - offset)) * weights[i];
+  }
+  fragColor = sum;
+}
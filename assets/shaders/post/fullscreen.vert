@@ -0,0 +1,10 @@
+#version 460
+
+out vec2 vUv;
+
+void main() {
+  // one triangle covering the screen, built from the vertex index alone
+  vec2 p = vec2((gl_VertexID << 1) & 2, gl_VertexID & 2);
+  vUv = p;
+  gl_Position = vec4(p * 2.0 - 1.0, 0.0, 1.0);
+}
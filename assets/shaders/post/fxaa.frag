@@ -0,0 +1,45 @@
+#version 460
+
+// FXAA after Timothy Lottes, expects gamma corrected colors
+
+in vec2 vUv;
+out vec4 fragColor;
+
+uniform sampler2D uInput;
+uniform vec2 uResolution;
+
+const float SPAN_MAX = 8.0;
+const float REDUCE_MUL = 1.0 / 8.0;
+const float REDUCE_MIN = 1.0 / 128.0;
+
+float luma(vec3 c) {
+  return dot(c, vec3(0.299, 0.587, 0.114));
+}
+
+void main() {
+  vec2 texel = 1.0 / uResolution;
+  vec4 center = texture(uInput, vUv);
+
+  float lumaNW = luma(texture(uInput, vUv + vec2(-1.0, -1.0) * texel).rgb);
+  float lumaNE = luma(texture(uInput, vUv + vec2(1.0, -1.0) * texel).rgb);
+  float lumaSW = luma(texture(uInput, vUv + vec2(-1.0, 1.0) * texel).rgb);
+  float lumaSE = luma(texture(uInput, vUv + vec2(1.0, 1.0) * texel).rgb);
+  float lumaM = luma(center.rgb);
+
+  float lumaMin = min(lumaM, min(min(lumaNW, lumaNE), min(lumaSW, lumaSE)));
+  float lumaMax = max(lumaM, max(max(lumaNW, lumaNE), max(lumaSW, lumaSE)));
+
+  // blur along the edge, perpendicular to the luma gradient
+  vec2 dir = vec2(-((lumaNW + lumaNE) - (lumaSW + lumaSE)), (lumaNW + lumaSW) - (lumaNE + lumaSE));
+  float reduce = max((lumaNW + lumaNE + lumaSW + lumaSE) * 0.25 * REDUCE_MUL, REDUCE_MIN);
+  float scale = 1.0 / (min(abs(dir.x), abs(dir.y)) + reduce);
+  dir = clamp(dir * scale, -SPAN_MAX, SPAN_MAX) * texel;
+
+  vec3 rgbA = 0.5 * (texture(uInput, vUv + dir * (1.0 / 3.0 - 0.5)).rgb +
+                     texture(uInput, vUv + dir * (2.0 / 3.0 - 0.5)).rgb);
+  vec3 rgbB = rgbA * 0.5 + 0.25 * (texture(uInput, vUv - dir * 0.5).rgb +
+                                   texture(uInput, vUv + dir * 0.5).rgb);
+
+  float lumaB = luma(rgbB);
+  fragColor = vec4((lumaB < lumaMin || lumaB > lumaMax) ? rgbA : rgbB, center.a);
+}
@@ -0,0 +1,12 @@
+#version 460
+
+in vec2 vUv;
+out vec4 fragColor;
+
+uniform sampler2D uInput;
+uniform float uGamma;
+
+void main() {
+  vec4 color = texture(uInput, vUv);
+  fragColor = vec4(pow(max(color.rgb, 0.0), vec3(1.0 / uGamma)), color.a);
+}
@@ -0,0 +1,28 @@
+#version 460
+
+in vec2 vUv;
+out vec4 fragColor;
+
+uniform sampler2D uInput;
+uniform sampler2D uLUT; // N slices of N by N side by side, blue selects the slice
+uniform float uIntensity;
+
+vec3 grade(vec3 c) {
+  vec2 size = vec2(textureSize(uLUT, 0));
+  float n = size.y;
+  c = clamp(c, 0.0, 1.0);
+
+  float blue = c.b * (n - 1.0);
+  float slice0 = floor(blue);
+  float slice1 = min(slice0 + 1.0, n - 1.0);
+
+  vec2 uv = vec2((c.r * (n - 1.0) + 0.5) / size.x, (c.g * (n - 1.0) + 0.5) / size.y);
+  vec3 a = texture(uLUT, uv + vec2(slice0 * n / size.x, 0.0)).rgb;
+  vec3 b = texture(uLUT, uv + vec2(slice1 * n / size.x, 0.0)).rgb;
+  return mix(a, b, blue - slice0);
+}
+
+void main() {
+  vec4 color = texture(uInput, vUv);
+  fragColor = vec4(mix(color.rgb, grade(color.rgb), uIntensity), color.a);
+}
@@ -0,0 +1,32 @@
+#version 460
+
+#define TONEMAP_ACES 0
+#define TONEMAP_REINHARD 1
+
+in vec2 vUv;
+out vec4 fragColor;
+
+uniform sampler2D uInput;
+uniform float uExposure;
+uniform int uOperator;
+
+// fit of the ACES filmic curve by Krzysztof Narkowicz
+vec3 aces(vec3 x) {
+  const float a = 2.51;
+  const float b = 0.03;
+  const float c = 2.43;
+  const float d = 0.59;
+  const float e = 0.14;
+  return clamp((x * (a * x + b)) / (x * (c * x + d) + e), 0.0, 1.0);
+}
+
+void main() {
+  vec4 color = texture(uInput, vUv);
+  vec3 hdr = color.rgb * uExposure;
+
+  if (uOperator == TONEMAP_REINHARD) {
+    fragColor = vec4(hdr / (hdr + vec3(1.0)), color.a);
+  } else {
+    fragColor = vec4(aces(hdr), color.a);
+  }
+}
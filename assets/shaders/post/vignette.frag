@@ -0,0 +1,16 @@
+#version 460
+
+in vec2 vUv;
+out vec4 fragColor;
+
+uniform sampler2D uInput;
+uniform float uIntensity;
+uniform float uRadius;   // distance from the center where darkening ends
+uniform float uSoftness; // width of the transition
+
+void main() {
+  vec4 color = texture(uInput, vUv);
+  float d = distance(vUv, vec2(0.5));
+  float v = smoothstep(uRadius, uRadius - uSoftness, d);
+  fragColor = vec4(color.rgb * mix(1.0, v, uIntensity), color.a);
+}
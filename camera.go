@@ -0,0 +1,107 @@
+package noor
+
+import "github.com/ahmedsat/madar"
+
+// AspectCamera is implemented by cameras whose projection depends on the
+// framebuffer aspect ratio. Noor calls SetAspect whenever the framebuffer is resized.
+type AspectCamera interface {
+	Camera
+	SetAspect(aspect float32)
+}
+
+// LookAt places a camera at Position looking towards Target. It is embedded by
+// the camera types and is what camera controllers move around.
+type LookAt struct {
+	Position madar.Vector3
+	Target   madar.Vector3
+	Up       madar.Vector3
+
+	view [16]float32
+}
+
+func NewLookAt(position, target madar.Vector3) LookAt {
+	return LookAt{
+		Position: position,
+		Target:   target,
+		Up:       madar.Vector3{X: 0, Y: 1, Z: 0},
+	}
+}
+
+func (l *LookAt) View() *float32 {
+	l.view = lookAt(l.Position, l.Target, l.Up)
+	return &l.view[0]
+}
+
+// Forward returns the normalized direction from Position to Target.
+func (l *LookAt) Forward() madar.Vector3 {
+	return normalize(sub(l.Target, l.Position))
+}
+
+type PerspectiveCamera struct {
+	LookAt
+
+	Fov    float32 // vertical field of view in degrees
+	Aspect float32
+	Near   float32
+	Far    float32
+
+	projection [16]float32
+}
+
+func NewPerspectiveCamera(position, target madar.Vector3, fov, aspect, near, far float32) *PerspectiveCamera {
+	return &PerspectiveCamera{
+		LookAt: NewLookAt(position, target),
+		Fov:    fov,
+		Aspect: aspect,
+		Near:   near,
+		Far:    far,
+	}
+}
+
+func (c *PerspectiveCamera) Projection() *float32 {
+	c.projection = perspective(c.Fov, c.Aspect, c.Near, c.Far)
+	return &c.projection[0]
+}
+
+func (c *PerspectiveCamera) SetAspect(aspect float32) {
+	c.Aspect = aspect
+}
+
+type OrthographicCamera struct {
+	LookAt
+
+	Left   float32
+	Right  float32
+	Bottom float32
+	Top    float32
+	Near   float32
+	Far    float32
+
+	projection [16]float32
+}
+
+func NewOrthographicCamera(position, target madar.Vector3, left, right, bottom, top, near, far float32) *OrthographicCamera {
+	return &OrthographicCamera{
+		LookAt: NewLookAt(position, target),
+		Left:   left,
+		Right:  right,
+		Bottom: bottom,
+		Top:    top,
+		Near:   near,
+		Far:    far,
+	}
+}
+
+func (c *OrthographicCamera) Projection() *float32 {
+	c.projection = orthographic(c.Left, c.Right, c.Bottom, c.Top, c.Near, c.Far)
+	return &c.projection[0]
+}
+
+// SetAspect keeps the vertical bounds and widens or narrows the horizontal
+// bounds around their center so the projection matches the new aspect ratio.
+func (c *OrthographicCamera) SetAspect(aspect float32) {
+	center := (c.Left + c.Right) / 2
+	halfWidth := (c.Top - c.Bottom) * aspect / 2
+	c.Left = center - halfWidth
+	c.Right = center + halfWidth
+}
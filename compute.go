@@ -0,0 +1,161 @@
+package noor
+
+import (
+	"fmt"
+	"unsafe"
+
+	"github.com/go-gl/gl/v4.6-core/gl"
+)
+
+// ComputeShader is a program made of a single compute stage. The uniform
+// setters and reflection of Shader work on it as well.
+type ComputeShader struct {
+	Shader
+}
+
+func CreateComputeShader(source string) Result[*ComputeShader] {
+	sh := linkProgram(shaderStage{kind: gl.COMPUTE_SHADER, source: source})
+	if sh.IsErr() {
+		return Err[*ComputeShader](sh.Err)
+	}
+	return Ok(&ComputeShader{Shader: sh.Ok})
+}
+
+func CreateComputeShaderFromFile(path string) Result[*ComputeShader] {
+	source := loadShaderSourceFromFile(path)
+	if source.IsErr() {
+		return Err[*ComputeShader](source.Err)
+	}
+	return CreateComputeShader(source.Ok)
+}
+
+// LocalSize returns the work group size declared with layout(local_size_x = ...).
+func (cs *ComputeShader) LocalSize() [3]int32 {
+	var size [3]int32
+	gl.GetProgramiv(uint32(cs.Shader), gl.COMPUTE_WORK_GROUP_SIZE, &size[0])
+	return size
+}
+
+// Dispatch runs x*y*z work groups.
+func (cs *ComputeShader) Dispatch(x, y, z uint32) {
+	cs.Activate()
+	gl.DispatchCompute(x, y, z)
+}
+
+// DispatchSize runs enough work groups to cover width*height*depth invocations.
+func (cs *ComputeShader) DispatchSize(width, height, depth uint32) {
+	local := cs.LocalSize()
+	groups := func(n uint32, size int32) uint32 {
+		return (n + uint32(size) - 1) / uint32(size)
+	}
+	cs.Dispatch(groups(width, local[0]), groups(height, local[1]), groups(depth, local[2]))
+}
+
+// Barrier is a set of gl.*_BARRIER_BIT flags for MemoryBarrier.
+type Barrier uint32
+
+const (
+	BarrierVertexAttribArray Barrier = gl.VERTEX_ATTRIB_ARRAY_BARRIER_BIT
+	BarrierElementArray      Barrier = gl.ELEMENT_ARRAY_BARRIER_BIT
+	BarrierUniform           Barrier = gl.UNIFORM_BARRIER_BIT
+	BarrierTextureFetch      Barrier = gl.TEXTURE_FETCH_BARRIER_BIT
+	BarrierImageAccess       Barrier = gl.SHADER_IMAGE_ACCESS_BARRIER_BIT
+	BarrierCommand           Barrier = gl.COMMAND_BARRIER_BIT
+	BarrierBufferUpdate      Barrier = gl.BUFFER_UPDATE_BARRIER_BIT
+	BarrierTextureUpdate     Barrier = gl.TEXTURE_UPDATE_BARRIER_BIT
+	BarrierFramebuffer       Barrier = gl.FRAMEBUFFER_BARRIER_BIT
+	BarrierShaderStorage     Barrier = gl.SHADER_STORAGE_BARRIER_BIT
+	BarrierAll               Barrier = gl.ALL_BARRIER_BITS
+)
+
+// MemoryBarrier makes writes of previous dispatches visible to the given kinds of access,
+// e.g. BarrierShaderStorage|BarrierVertexAttribArray before drawing particles
+// a compute shader has updated.
+func MemoryBarrier(barriers Barrier) {
+	gl.MemoryBarrier(uint32(barriers))
+}
+
+// StorageBuffer is a shader storage buffer object.
+type StorageBuffer struct {
+	Handle uint32
+	Size   int // in bytes
+}
+
+// NewStorageBuffer creates a storage buffer holding data.
+func NewStorageBuffer[T any](data []T) *StorageBuffer {
+	b := &StorageBuffer{}
+	gl.GenBuffers(1, &b.Handle)
+	SetStorageBufferData(b, data)
+	return b
+}
+
+// NewEmptyStorageBuffer creates a zeroed storage buffer of size bytes.
+func NewEmptyStorageBuffer(size int) *StorageBuffer {
+	return NewStorageBuffer(make([]byte, size))
+}
+
+// SetStorageBufferData replaces the content of the buffer, resizing it to fit data.
+func SetStorageBufferData[T any](b *StorageBuffer, data []T) {
+	b.Size = len(data) * int(unsafe.Sizeof(*new(T)))
+	gl.BindBuffer(gl.SHADER_STORAGE_BUFFER, b.Handle)
+	gl.BufferData(gl.SHADER_STORAGE_BUFFER, b.Size, bufferPtr(data), gl.DYNAMIC_DRAW)
+	gl.BindBuffer(gl.SHADER_STORAGE_BUFFER, 0)
+}
+
+// ReadStorageBuffer copies the start of the buffer into out. Issue
+// MemoryBarrier(BarrierBufferUpdate) after the dispatch that wrote it.
+func ReadStorageBuffer[T any](b *StorageBuffer, out []T) {
+	size := min(len(out)*int(unsafe.Sizeof(*new(T))), b.Size)
+	if size == 0 {
+		return
+	}
+	gl.BindBuffer(gl.SHADER_STORAGE_BUFFER, b.Handle)
+	gl.GetBufferSubData(gl.SHADER_STORAGE_BUFFER, 0, size, gl.Ptr(out))
+	gl.BindBuffer(gl.SHADER_STORAGE_BUFFER, 0)
+}
+
+// Bind binds the buffer to a binding point, layout(binding = n) in GLSL.
+func (b *StorageBuffer) Bind(binding uint32) {
+	gl.BindBufferBase(gl.SHADER_STORAGE_BUFFER, binding, b.Handle)
+}
+
+func (b *StorageBuffer) Delete() {
+	if b.Handle != 0 {
+		gl.DeleteBuffers(1, &b.Handle)
+		b.Handle = 0
+	}
+}
+
+// BindStorageBuffer binds b to the binding point of the named storage block.
+func (sh *Shader) BindStorageBuffer(blockName string, b *StorageBuffer) error {
+	for _, block := range sh.Reflect().StorageBlocks {
+		if block.Name == blockName {
+			b.Bind(uint32(block.Binding))
+			return nil
+		}
+	}
+	return fmt.Errorf("storage block %s not found", blockName)
+}
+
+// ImageAccess tells how a shader uses an image bound with BindImage.
+type ImageAccess uint32
+
+const (
+	ReadOnly  ImageAccess = gl.READ_ONLY
+	WriteOnly ImageAccess = gl.WRITE_ONLY
+	ReadWrite ImageAccess = gl.READ_WRITE
+)
+
+// BindImage binds a mip level of the texture to an image unit, layout(binding = n)
+// of an image2D in GLSL. The image format in the shader must match tex.Format.
+func (tex *Texture) BindImage(unit uint32, level int32, access ImageAccess) {
+	layered := tex.Type == TextureArray2D || tex.Type == TextureCubemap
+	gl.BindImageTexture(unit, tex.Handle, level, layered, 0, uint32(access), uint32(tex.Format))
+}
+
+func bufferPtr[T any](data []T) unsafe.Pointer {
+	if len(data) == 0 {
+		return nil
+	}
+	return gl.Ptr(data)
+}
@@ -0,0 +1,163 @@
+package noor
+
+import (
+	"math"
+
+	"github.com/ahmedsat/madar"
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+// Controller is updated once per frame by Noor.Loop before the user update function.
+type Controller interface {
+	Update(input *Input, deltaTime float32)
+}
+
+// FlyController moves a camera like a first-person fly camera:
+// WASD to move, Space / Left Control to go up and down, Left Shift to go faster,
+// and mouse look.
+type FlyController struct {
+	Camera *LookAt
+
+	Speed       float32 // units per second
+	BoostFactor float32 // speed multiplier while Left Shift is held
+	Sensitivity float32 // degrees per pixel of mouse movement
+
+	Yaw   float32 // degrees, 0 looks down -Z
+	Pitch float32 // degrees, clamped to (-89, 89)
+
+	// CaptureCursor locks the cursor to the window on the first update.
+	CaptureCursor bool
+}
+
+func NewFlyController(camera *LookAt) *FlyController {
+	forward := camera.Forward()
+	return &FlyController{
+		Camera:        camera,
+		Speed:         3,
+		BoostFactor:   3,
+		Sensitivity:   0.1,
+		Yaw:           degrees(float32(math.Atan2(float64(forward.X), float64(-forward.Z)))),
+		Pitch:         degrees(float32(math.Asin(float64(forward.Y)))),
+		CaptureCursor: true,
+	}
+}
+
+func (c *FlyController) Update(input *Input, deltaTime float32) {
+	if c.CaptureCursor && !input.CursorCaptured() {
+		input.CaptureCursor(true)
+	}
+
+	// without cursor capture, look around while the right button is held
+	if input.CursorCaptured() || input.MouseDown(glfw.MouseButtonRight) {
+		dx, dy := input.CursorDelta()
+		c.Yaw += dx * c.Sensitivity
+		c.Pitch = clamp(c.Pitch-dy*c.Sensitivity, -89, 89)
+	}
+
+	forward := direction(c.Yaw, c.Pitch)
+	right := normalize(cross(forward, c.Camera.Up))
+	up := c.Camera.Up
+
+	speed := c.Speed * deltaTime
+	if input.KeyDown(glfw.KeyLeftShift) {
+		speed *= c.BoostFactor
+	}
+
+	move := madar.Vector3{}
+	if input.KeyDown(glfw.KeyW) {
+		move = add(move, forward)
+	}
+	if input.KeyDown(glfw.KeyS) {
+		move = sub(move, forward)
+	}
+	if input.KeyDown(glfw.KeyD) {
+		move = add(move, right)
+	}
+	if input.KeyDown(glfw.KeyA) {
+		move = sub(move, right)
+	}
+	if input.KeyDown(glfw.KeySpace) {
+		move = add(move, up)
+	}
+	if input.KeyDown(glfw.KeyLeftControl) {
+		move = sub(move, up)
+	}
+
+	c.Camera.Position = add(c.Camera.Position, scale(normalize(move), speed))
+	c.Camera.Target = add(c.Camera.Position, forward)
+}
+
+// OrbitController rotates a camera around its target:
+// left drag to orbit, scroll to zoom and middle drag to pan.
+type OrbitController struct {
+	Camera *LookAt
+
+	Distance float32
+	Yaw      float32 // degrees around the Y axis
+	Pitch    float32 // degrees, clamped to (-89, 89)
+
+	RotateSpeed float32 // degrees per pixel
+	ZoomSpeed   float32 // fraction of the distance per scroll step
+	PanSpeed    float32 // fraction of the distance per pixel
+
+	MinDistance float32
+	MaxDistance float32
+}
+
+func NewOrbitController(camera *LookAt) *OrbitController {
+	offset := sub(camera.Position, camera.Target)
+	distance := length(offset)
+	dir := normalize(offset)
+	return &OrbitController{
+		Camera:      camera,
+		Distance:    distance,
+		Yaw:         degrees(float32(math.Atan2(float64(dir.X), float64(dir.Z)))),
+		Pitch:       degrees(float32(math.Asin(float64(dir.Y)))),
+		RotateSpeed: 0.3,
+		ZoomSpeed:   0.1,
+		PanSpeed:    0.002,
+		MinDistance: 0.1,
+		MaxDistance: 1000,
+	}
+}
+
+func (c *OrbitController) Update(input *Input, deltaTime float32) {
+	dx, dy := input.CursorDelta()
+
+	if input.MouseDown(glfw.MouseButtonLeft) {
+		c.Yaw -= dx * c.RotateSpeed
+		c.Pitch = clamp(c.Pitch+dy*c.RotateSpeed, -89, 89)
+	}
+
+	if input.MouseDown(glfw.MouseButtonMiddle) {
+		forward := normalize(sub(c.Camera.Target, c.Camera.Position))
+		right := normalize(cross(forward, c.Camera.Up))
+		up := cross(right, forward)
+		pan := add(scale(right, -dx), scale(up, dy))
+		c.Camera.Target = add(c.Camera.Target, scale(pan, c.PanSpeed*c.Distance))
+	}
+
+	if _, scroll := input.Scroll(); scroll != 0 {
+		c.Distance *= float32(math.Pow(float64(1-c.ZoomSpeed), float64(scroll)))
+		c.Distance = clamp(c.Distance, c.MinDistance, c.MaxDistance)
+	}
+
+	yaw, pitch := float64(radians(c.Yaw)), float64(radians(c.Pitch))
+	offset := madar.Vector3{
+		X: float32(math.Cos(pitch) * math.Sin(yaw)),
+		Y: float32(math.Sin(pitch)),
+		Z: float32(math.Cos(pitch) * math.Cos(yaw)),
+	}
+	c.Camera.Position = add(c.Camera.Target, scale(offset, c.Distance))
+}
+
+// direction returns the unit vector for a yaw and pitch in degrees,
+// where zero yaw and pitch look down -Z.
+func direction(yaw, pitch float32) madar.Vector3 {
+	y, p := float64(radians(yaw)), float64(radians(pitch))
+	return madar.Vector3{
+		X: float32(math.Cos(p) * math.Sin(y)),
+		Y: float32(math.Sin(p)),
+		Z: float32(-math.Cos(p) * math.Cos(y)),
+	}
+}
@@ -4,6 +4,7 @@ import (
 	_ "embed"
 	"fmt"
 	"image/color"
+	"os"
 	"runtime"
 	"strings"
 	"time"
@@ -12,20 +13,50 @@ import (
 	"github.com/go-gl/glfw/v3.3/glfw"
 )
 
-// embed default shaders in the binary using go:embed
+// embed default shaders in the binary using go:embed; they #include the
+// files of ShaderIncludes
 //
 //go:embed assets/shaders/default.vert
-var DefaultVertexShader string
+var defaultVertexSource string
 
 //go:embed assets/shaders/default.frag
-var DefaultFragmentShader string
+var defaultFragmentSource string
+
+// DefaultVertexShader and DefaultFragmentShader are the default shaders with
+// their includes expanded, sized for 8 lights.
+var (
+	DefaultVertexShader   = expandDefaultShader("default.vert", defaultVertexSource, nil).Code
+	DefaultFragmentShader = expandDefaultShader("default.frag", defaultFragmentSource, nil).Code
+)
 
 type Noor struct {
 	*glfw.Window
 	*Scene
+	Input *Input
+
+	controllers []Controller
+
+	// Post runs the rendered scene through full screen effects before it is
+	// shown, see EnablePostProcessing.
+	Post *PostStack
+
+	shaderReloadInterval time.Duration
+	lastShaderCheck      time.Time
+
+	// target is where RenderImage renders, see NewHeadless
+	target   *Framebuffer
+	headless bool
+
+	screenshotKey  glfw.Key
+	screenshotDir  string
+	screenshotHeld bool
 }
 
 func New(width, height int, title string, bg color.Color) Result[Noor] {
+	return create(width, height, title, bg, true)
+}
+
+func create(width, height int, title string, bg color.Color, visible bool) Result[Noor] {
 
 	if !IsLockedToThread() {
 		fmt.Println("WARNING: The current goroutine is not locked to a thread. This may cause issues with the OpenGL context.")
@@ -44,6 +75,9 @@ func New(width, height int, title string, bg color.Color) Result[Noor] {
 	}
 
 	setWindowHints()
+	if !visible {
+		glfw.WindowHint(glfw.Visible, glfw.False)
+	}
 
 	noor.Window, err = glfw.CreateWindow(width, height, title, nil, nil)
 	if err != nil {
@@ -53,6 +87,7 @@ func New(width, height int, title string, bg color.Color) Result[Noor] {
 	noor.Window.MakeContextCurrent()
 
 	noor.Window.SetInputMode(glfw.StickyKeysMode, glfw.True)
+	noor.Input = newInput(noor.Window)
 
 	if err = gl.Init(); err != nil {
 		return Err[Noor](err)
@@ -60,6 +95,8 @@ func New(width, height int, title string, bg color.Color) Result[Noor] {
 
 	gl.Enable(gl.DEPTH_TEST)
 
+	noor.Window.SetFramebufferSizeCallback(noor.framebufferResized)
+
 	r, g, b, a := bg.RGBA()
 	gl.ClearColor(float32(r)/float32(0xffff), float32(g)/float32(0xffff), float32(b)/float32(0xffff), float32(a)/float32(0xffff))
 
@@ -76,6 +113,10 @@ func setWindowHints() {
 
 func (n *Noor) Loop(update func(float32)) {
 
+	// New returns a copy, so the callback set by create would not see the
+	// scene if it is replaced before the loop
+	n.Window.SetFramebufferSizeCallback(n.framebufferResized)
+
 	lastFrameTime := time.Now()
 
 	for !n.Window.ShouldClose() {
@@ -87,10 +128,17 @@ func (n *Noor) Loop(update func(float32)) {
 			n.Window.SetShouldClose(true)
 		}
 
+		n.reloadShaders()
+
+		n.Input.update()
+		for _, c := range n.controllers {
+			c.Update(n.Input, float32(deltaTime))
+		}
+
 		update(float32(deltaTime))
 
-		gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
-		n.Render()
+		n.renderFrame()
+		n.checkScreenshotKey()
 
 		glfw.PollEvents()
 		n.Window.SwapBuffers()
@@ -99,17 +147,105 @@ func (n *Noor) Loop(update func(float32)) {
 
 }
 
+// framebufferResized follows the new size with the viewport and the aspect of
+// the camera of the current scene.
+func (n *Noor) framebufferResized(w *glfw.Window, width, height int) {
+	gl.Viewport(0, 0, int32(width), int32(height))
+	if cam, ok := n.Scene.Camera.(AspectCamera); ok && height > 0 {
+		cam.SetAspect(float32(width) / float32(height))
+	}
+}
+
+func (n *Noor) renderFrame() {
+	if n.Post == nil {
+		clearBuffers()
+		n.Render()
+		return
+	}
+
+	width, height := n.Window.GetFramebufferSize()
+	if err := n.Post.Resize(int32(width), int32(height)); err != nil {
+		fmt.Fprintf(os.Stderr, "Error : resizing post processing buffers: %v\n", err)
+	}
+	n.Post.Render(n.Scene)
+}
+
+// EnablePostProcessing creates n.Post, which Loop renders the scene through.
+// Add passes to it, e.g.
+//
+//	post, _ := n.EnablePostProcessing(noor.FormatRGBA16F)
+//	post.Add("tonemap", noor.NewTonemapEffect(noor.ToneMappingACES, 1).UnwrapOrPanic())
+//	post.Add("gamma", noor.NewGammaEffect(2.2).UnwrapOrPanic())
+func (n *Noor) EnablePostProcessing(format TextureFormat) (*PostStack, error) {
+	if n.Post != nil {
+		return n.Post, nil
+	}
+	width, height := n.Window.GetFramebufferSize()
+	post, err := NewPostStack(int32(width), int32(height), format)
+	if err != nil {
+		return nil, err
+	}
+	n.Post = post
+	return post, nil
+}
+
+// AddController registers a controller to be updated every frame by Loop.
+func (n *Noor) AddController(c Controller) {
+	n.controllers = append(n.controllers, c)
+}
+
+func (n *Noor) RemoveController(c Controller) {
+	for i, ctrl := range n.controllers {
+		if ctrl == c {
+			n.controllers = append(n.controllers[:i], n.controllers[i+1:]...)
+			break
+		}
+	}
+}
+
 func (n *Noor) SetBackground(bg color.Color) {
 	r, g, b, a := bg.RGBA()
 	gl.ClearColor(float32(r)/float32(0xffff), float32(g)/float32(0xffff), float32(b)/float32(0xffff), float32(a)/float32(0xffff))
 }
 
+// Aspect returns the current framebuffer aspect ratio (width / height).
+func (n *Noor) Aspect() float32 {
+	width, height := n.Window.GetFramebufferSize()
+	if height == 0 {
+		return 1
+	}
+	return float32(width) / float32(height)
+}
+
 func (n *Noor) Close() {
+	if n.target != nil {
+		n.target.Delete()
+		n.target = nil
+	}
+
+	if n.Post != nil {
+		n.Post.Delete()
+		n.Post = nil
+	}
 
 	n.Window.SetShouldClose(true)
 
 	n.Window.Destroy()
 	glfw.Terminate()
+	resetGLState()
+}
+
+// resetGLState forgets what the package caches about the GL context, whose
+// objects die with it, so a context created later starts clean.
+func resetGLState() {
+	clear(shaderEntries)
+	clear(sharedShaders)
+	clear(uniformCaches)
+	clear(programReflections)
+	clear(watchedShaders)
+	stateCache.valid = false
+	fullscreenVAO = 0
+	driverID = ""
 }
 
 func IsLockedToThread() bool {
@@ -0,0 +1,279 @@
+package noor
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/go-gl/gl/v4.6-core/gl"
+)
+
+type DepthAttachment int
+
+const (
+	DepthNone         DepthAttachment = iota
+	DepthRenderbuffer                 // depth and stencil storage that cannot be sampled
+	DepthTexture                      // a depth texture that can be sampled, e.g. for shadow maps
+)
+
+// BufferMask selects the buffers of a framebuffer.
+type BufferMask uint32
+
+const (
+	ColorBuffer   BufferMask = gl.COLOR_BUFFER_BIT
+	DepthBuffer   BufferMask = gl.DEPTH_BUFFER_BIT
+	StencilBuffer BufferMask = gl.STENCIL_BUFFER_BIT
+)
+
+type FramebufferOptions struct {
+	// Colors has the format of each color attachment. Fragment shader output
+	// location i is written to attachment i. Leave it empty for a depth only
+	// framebuffer.
+	Colors []TextureFormat
+
+	Depth       DepthAttachment
+	DepthFormat TextureFormat // FormatDepth24Stencil8 when zero
+
+	// Filtering of the color textures when sampled, Linear when zero.
+	Filtering TextureFiltering
+}
+
+// DefaultFramebufferOptions has one FormatRGBA8 color texture and a depth
+// stencil renderbuffer, enough to render a scene into a texture.
+func DefaultFramebufferOptions() FramebufferOptions {
+	return FramebufferOptions{
+		Colors: []TextureFormat{FormatRGBA8},
+		Depth:  DepthRenderbuffer,
+	}
+}
+
+// Framebuffer is an offscreen render target. Its color attachments are
+// textures, so what is rendered into it can be drawn by other objects.
+type Framebuffer struct {
+	Handle        uint32
+	Width, Height int32
+
+	Colors []*Texture
+	Depth  *Texture // set with DepthTexture
+
+	options      FramebufferOptions
+	renderbuffer uint32
+}
+
+func NewFramebuffer(width, height int32, options FramebufferOptions) (*Framebuffer, error) {
+	if options.Depth != DepthNone && options.DepthFormat == 0 {
+		options.DepthFormat = FormatDepth24Stencil8
+	}
+	if options.Filtering == 0 {
+		options.Filtering = Linear
+	}
+	if options.Depth != DepthNone && !options.DepthFormat.IsDepth() {
+		return nil, fmt.Errorf("failed to create framebuffer: 0x%x is not a depth format", options.DepthFormat)
+	}
+
+	fb := &Framebuffer{Width: width, Height: height, options: options}
+	gl.CreateFramebuffers(1, &fb.Handle)
+
+	if err := fb.attach(); err != nil {
+		fb.Delete()
+		return nil, fmt.Errorf("failed to create framebuffer: %w", err)
+	}
+	return fb, nil
+}
+
+// attach creates the attachments at the framebuffer size.
+func (fb *Framebuffer) attach() error {
+	drawBuffers := make([]uint32, len(fb.options.Colors))
+	for i, format := range fb.options.Colors {
+		name := "uTexture"
+		if i > 0 {
+			name = fmt.Sprintf("uTexture%d", i)
+		}
+		tex, err := NewEmptyTexture(fb.Width, fb.Height, name, TextureParameters{
+			WrappingS:    ClampToEdge,
+			WrappingT:    ClampToEdge,
+			FilteringMin: fb.options.Filtering,
+			FilteringMag: fb.options.Filtering,
+			Format:       format,
+		})
+		if err != nil {
+			return fmt.Errorf("color attachment %d: %w", i, err)
+		}
+		tex.BottomUp = true // rendered rows start at the bottom
+		fb.Colors = append(fb.Colors, tex)
+
+		drawBuffers[i] = gl.COLOR_ATTACHMENT0 + uint32(i)
+		gl.NamedFramebufferTexture(fb.Handle, drawBuffers[i], tex.Handle, 0)
+	}
+
+	if len(drawBuffers) > 0 {
+		gl.NamedFramebufferDrawBuffers(fb.Handle, int32(len(drawBuffers)), &drawBuffers[0])
+		gl.NamedFramebufferReadBuffer(fb.Handle, gl.COLOR_ATTACHMENT0)
+	} else {
+		gl.NamedFramebufferDrawBuffer(fb.Handle, gl.NONE)
+		gl.NamedFramebufferReadBuffer(fb.Handle, gl.NONE)
+	}
+
+	attachment := uint32(gl.DEPTH_ATTACHMENT)
+	if fb.options.DepthFormat == FormatDepth24Stencil8 {
+		attachment = gl.DEPTH_STENCIL_ATTACHMENT
+	}
+
+	switch fb.options.Depth {
+	case DepthRenderbuffer:
+		gl.CreateRenderbuffers(1, &fb.renderbuffer)
+		gl.NamedRenderbufferStorage(fb.renderbuffer, uint32(fb.options.DepthFormat), fb.Width, fb.Height)
+		gl.NamedFramebufferRenderbuffer(fb.Handle, attachment, gl.RENDERBUFFER, fb.renderbuffer)
+
+	case DepthTexture:
+		tex, err := NewEmptyTexture(fb.Width, fb.Height, "uDepth", TextureParameters{
+			WrappingS:    ClampToEdge,
+			WrappingT:    ClampToEdge,
+			FilteringMin: Nearest,
+			FilteringMag: Nearest,
+			Format:       fb.options.DepthFormat,
+		})
+		if err != nil {
+			return fmt.Errorf("depth attachment: %w", err)
+		}
+		tex.BottomUp = true
+		fb.Depth = tex
+		gl.NamedFramebufferTexture(fb.Handle, attachment, tex.Handle, 0)
+	}
+
+	if err := checkGLError("attaching framebuffer buffers"); err != nil {
+		return err
+	}
+	return fb.Check()
+}
+
+// Check returns why the framebuffer cannot be rendered to, or nil when it is complete.
+func (fb *Framebuffer) Check() error {
+	status := gl.CheckNamedFramebufferStatus(fb.Handle, gl.FRAMEBUFFER)
+	if status == gl.FRAMEBUFFER_COMPLETE {
+		return nil
+	}
+	if reason, ok := framebufferStatus[status]; ok {
+		return errors.New("framebuffer incomplete: " + reason)
+	}
+	return fmt.Errorf("framebuffer incomplete: status 0x%x", status)
+}
+
+var framebufferStatus = map[uint32]string{
+	gl.FRAMEBUFFER_UNDEFINED:                     "the default framebuffer does not exist",
+	gl.FRAMEBUFFER_INCOMPLETE_ATTACHMENT:         "an attachment is incomplete or has a zero size",
+	gl.FRAMEBUFFER_INCOMPLETE_MISSING_ATTACHMENT: "no image is attached",
+	gl.FRAMEBUFFER_INCOMPLETE_DRAW_BUFFER:        "a draw buffer has no attachment",
+	gl.FRAMEBUFFER_INCOMPLETE_READ_BUFFER:        "the read buffer has no attachment",
+	gl.FRAMEBUFFER_UNSUPPORTED:                   "the combination of attachment formats is not supported by the driver",
+	gl.FRAMEBUFFER_INCOMPLETE_MULTISAMPLE:        "attachments have different sample counts",
+	gl.FRAMEBUFFER_INCOMPLETE_LAYER_TARGETS:      "attachments are not all layered",
+}
+
+// Texture returns color attachment i.
+func (fb *Framebuffer) Texture(i int) *Texture {
+	return fb.Colors[i]
+}
+
+// Bind makes the framebuffer the target of draw calls and sets the viewport to its size.
+func (fb *Framebuffer) Bind() {
+	gl.BindFramebuffer(gl.FRAMEBUFFER, fb.Handle)
+	gl.Viewport(0, 0, fb.Width, fb.Height)
+}
+
+// BindDefaultFramebuffer draws to the window again, with a viewport of width by height.
+func BindDefaultFramebuffer(width, height int32) {
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	gl.Viewport(0, 0, width, height)
+}
+
+// Render clears the framebuffer and renders the scene into it, then restores
+// the previous framebuffer and viewport. An object of the scene must not
+// sample a texture of the framebuffer it is drawn into.
+func (fb *Framebuffer) Render(s *Scene) {
+	var previous int32
+	var viewport [4]int32
+	gl.GetIntegerv(gl.DRAW_FRAMEBUFFER_BINDING, &previous)
+	gl.GetIntegerv(gl.VIEWPORT, &viewport[0])
+
+	fb.Bind()
+	clearBuffers()
+	s.Render()
+
+	gl.BindFramebuffer(gl.FRAMEBUFFER, uint32(previous))
+	gl.Viewport(viewport[0], viewport[1], viewport[2], viewport[3])
+}
+
+// Resize reallocates the attachments at the new size. Textures keep their
+// handles, so objects using them do not need updating. The content is lost.
+func (fb *Framebuffer) Resize(width, height int32) error {
+	if width == fb.Width && height == fb.Height {
+		return nil
+	}
+	fb.Width, fb.Height = width, height
+
+	for i, tex := range fb.Colors {
+		if err := tex.Resize(width, height); err != nil {
+			return fmt.Errorf("failed to resize color attachment %d: %w", i, err)
+		}
+	}
+	if fb.Depth != nil {
+		if err := fb.Depth.Resize(width, height); err != nil {
+			return fmt.Errorf("failed to resize depth attachment: %w", err)
+		}
+	}
+	if fb.renderbuffer != 0 {
+		gl.NamedRenderbufferStorage(fb.renderbuffer, uint32(fb.options.DepthFormat), width, height)
+	}
+
+	return fb.Check()
+}
+
+// Blit copies the buffers in mask to dst, scaling when the sizes differ.
+// Color is read from attachment 0 and written to every draw buffer of dst.
+// A nil dst is the window, filled through the current viewport.
+// Depth and stencil are always copied with Nearest filtering.
+func (fb *Framebuffer) Blit(dst *Framebuffer, mask BufferMask, filter TextureFiltering) {
+	fb.BlitColor(0, dst, mask, filter)
+}
+
+// BlitColor is Blit reading color from attachment index.
+func (fb *Framebuffer) BlitColor(index int, dst *Framebuffer, mask BufferMask, filter TextureFiltering) {
+	if mask&(DepthBuffer|StencilBuffer) != 0 || filter == 0 {
+		filter = Nearest
+	}
+
+	var handle uint32
+	var width, height int32
+	if dst != nil {
+		handle, width, height = dst.Handle, dst.Width, dst.Height
+	} else {
+		var viewport [4]int32
+		gl.GetIntegerv(gl.VIEWPORT, &viewport[0])
+		width, height = viewport[2], viewport[3]
+	}
+
+	if mask&ColorBuffer != 0 {
+		gl.NamedFramebufferReadBuffer(fb.Handle, gl.COLOR_ATTACHMENT0+uint32(index))
+		defer gl.NamedFramebufferReadBuffer(fb.Handle, gl.COLOR_ATTACHMENT0)
+	}
+	gl.BlitNamedFramebuffer(fb.Handle, handle, 0, 0, fb.Width, fb.Height, 0, 0, width, height, uint32(mask), uint32(filter))
+}
+
+func (fb *Framebuffer) Delete() {
+	for _, tex := range fb.Colors {
+		tex.Delete()
+	}
+	fb.Colors = nil
+	if fb.Depth != nil {
+		fb.Depth.Delete()
+		fb.Depth = nil
+	}
+	if fb.renderbuffer != 0 {
+		gl.DeleteRenderbuffers(1, &fb.renderbuffer)
+		fb.renderbuffer = 0
+	}
+	if fb.Handle != 0 {
+		gl.DeleteFramebuffers(1, &fb.Handle)
+		fb.Handle = 0
+	}
+}
@@ -0,0 +1,913 @@
+package noor
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"math"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ahmedsat/madar"
+)
+
+// glTF 2.0 JSON schema, only the parts the importer uses.
+
+type gltfRoot struct {
+	Scene       *int             `json:"scene"`
+	Scenes      []gltfScene      `json:"scenes"`
+	Nodes       []gltfNode       `json:"nodes"`
+	Meshes      []gltfMesh       `json:"meshes"`
+	Materials   []gltfMaterial   `json:"materials"`
+	Textures    []gltfTexture    `json:"textures"`
+	Images      []gltfImage      `json:"images"`
+	Samplers    []gltfSampler    `json:"samplers"`
+	Cameras     []gltfCamera     `json:"cameras"`
+	Accessors   []gltfAccessor   `json:"accessors"`
+	BufferViews []gltfBufferView `json:"bufferViews"`
+	Buffers     []gltfBuffer     `json:"buffers"`
+}
+
+type gltfScene struct {
+	Name  string `json:"name"`
+	Nodes []int  `json:"nodes"`
+}
+
+type gltfNode struct {
+	Name        string       `json:"name"`
+	Children    []int        `json:"children"`
+	Mesh        *int         `json:"mesh"`
+	Camera      *int         `json:"camera"`
+	Matrix      *[16]float32 `json:"matrix"`
+	Translation *[3]float32  `json:"translation"`
+	Rotation    *[4]float32  `json:"rotation"`
+	Scale       *[3]float32  `json:"scale"`
+}
+
+type gltfMesh struct {
+	Name       string          `json:"name"`
+	Primitives []gltfPrimitive `json:"primitives"`
+}
+
+type gltfPrimitive struct {
+	Attributes map[string]int `json:"attributes"`
+	Indices    *int           `json:"indices"`
+	Material   *int           `json:"material"`
+	Mode       *uint32        `json:"mode"`
+}
+
+type gltfMaterial struct {
+	Name                 string `json:"name"`
+	PbrMetallicRoughness *struct {
+		BaseColorFactor  *[4]float32      `json:"baseColorFactor"`
+		BaseColorTexture *gltfTextureInfo `json:"baseColorTexture"`
+	} `json:"pbrMetallicRoughness"`
+	NormalTexture   *gltfTextureInfo `json:"normalTexture"`
+	EmissiveTexture *gltfTextureInfo `json:"emissiveTexture"`
+	EmissiveFactor  *[3]float32      `json:"emissiveFactor"`
+	AlphaMode       string           `json:"alphaMode"`
+	DoubleSided     bool             `json:"doubleSided"`
+}
+
+type gltfTextureInfo struct {
+	Index int `json:"index"`
+}
+
+type gltfTexture struct {
+	Sampler *int `json:"sampler"`
+	Source  *int `json:"source"`
+}
+
+type gltfImage struct {
+	Name       string `json:"name"`
+	URI        string `json:"uri"`
+	BufferView *int   `json:"bufferView"`
+	MimeType   string `json:"mimeType"`
+}
+
+type gltfSampler struct {
+	MagFilter int32 `json:"magFilter"`
+	MinFilter int32 `json:"minFilter"`
+	WrapS     int32 `json:"wrapS"`
+	WrapT     int32 `json:"wrapT"`
+}
+
+type gltfCamera struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Perspective *struct {
+		AspectRatio float32 `json:"aspectRatio"`
+		Yfov        float32 `json:"yfov"`
+		Znear       float32 `json:"znear"`
+		Zfar        float32 `json:"zfar"`
+	} `json:"perspective"`
+	Orthographic *struct {
+		Xmag  float32 `json:"xmag"`
+		Ymag  float32 `json:"ymag"`
+		Znear float32 `json:"znear"`
+		Zfar  float32 `json:"zfar"`
+	} `json:"orthographic"`
+}
+
+type gltfAccessor struct {
+	BufferView    *int   `json:"bufferView"`
+	ByteOffset    int    `json:"byteOffset"`
+	ComponentType uint32 `json:"componentType"`
+	Normalized    bool   `json:"normalized"`
+	Count         int    `json:"count"`
+	Type          string `json:"type"`
+	Sparse        *struct {
+		Count   int `json:"count"`
+		Indices struct {
+			BufferView    int    `json:"bufferView"`
+			ByteOffset    int    `json:"byteOffset"`
+			ComponentType uint32 `json:"componentType"`
+		} `json:"indices"`
+		Values struct {
+			BufferView int `json:"bufferView"`
+			ByteOffset int `json:"byteOffset"`
+		} `json:"values"`
+	} `json:"sparse"`
+}
+
+type gltfBufferView struct {
+	Buffer     int `json:"buffer"`
+	ByteOffset int `json:"byteOffset"`
+	ByteLength int `json:"byteLength"`
+	ByteStride int `json:"byteStride"`
+}
+
+type gltfBuffer struct {
+	URI        string `json:"uri"`
+	ByteLength int    `json:"byteLength"`
+}
+
+const (
+	gltfByte          = 5120
+	gltfUnsignedByte  = 5121
+	gltfShort         = 5122
+	gltfUnsignedShort = 5123
+	gltfUnsignedInt   = 5125
+	gltfFloat         = 5126
+
+	glbMagic     = 0x46546C67 // "glTF"
+	glbChunkJSON = 0x4E4F534A
+	glbChunkBIN  = 0x004E4942
+)
+
+var gltfComponents = map[string]int{
+	"SCALAR": 1, "VEC2": 2, "VEC3": 3, "VEC4": 4, "MAT2": 4, "MAT3": 9, "MAT4": 16,
+}
+
+// GLTFDocument is a parsed .gltf or .glb file with its buffers loaded.
+// It gives access to raw accessor data so meshes can be built with any vertex layout.
+type GLTFDocument struct {
+	root    gltfRoot
+	buffers [][]byte
+	dir     string
+}
+
+// OpenGLTF reads a .gltf or .glb file and the buffers it references.
+func OpenGLTF(path string) (*GLTFDocument, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read gltf file %s: %w", path, err)
+	}
+
+	doc, err := ParseGLTF(data, filepath.Dir(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse gltf file %s: %w", path, err)
+	}
+	return doc, nil
+}
+
+// ParseGLTF parses glTF JSON or a binary .glb container.
+// External buffers and images are resolved relative to dir.
+func ParseGLTF(data []byte, dir string) (*GLTFDocument, error) {
+	doc := &GLTFDocument{dir: dir}
+
+	jsonChunk, binChunk := data, []byte(nil)
+	if len(data) >= 12 && binary.LittleEndian.Uint32(data) == glbMagic {
+		var err error
+		if jsonChunk, binChunk, err = splitGLB(data); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := json.Unmarshal(jsonChunk, &doc.root); err != nil {
+		return nil, fmt.Errorf("invalid gltf json: %w", err)
+	}
+
+	doc.buffers = make([][]byte, len(doc.root.Buffers))
+	for i, buf := range doc.root.Buffers {
+		if buf.URI == "" {
+			if binChunk == nil {
+				return nil, fmt.Errorf("buffer %d has no uri and there is no glb binary chunk", i)
+			}
+			doc.buffers[i] = binChunk
+			continue
+		}
+
+		data, err := doc.readURI(buf.URI)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load buffer %d: %w", i, err)
+		}
+		if len(data) < buf.ByteLength {
+			return nil, fmt.Errorf("buffer %d is %d bytes, expected %d", i, len(data), buf.ByteLength)
+		}
+		doc.buffers[i] = data
+	}
+
+	return doc, nil
+}
+
+func splitGLB(data []byte) (jsonChunk, binChunk []byte, err error) {
+	if version := binary.LittleEndian.Uint32(data[4:]); version != 2 {
+		return nil, nil, fmt.Errorf("unsupported glb version %d", version)
+	}
+
+	length := int(binary.LittleEndian.Uint32(data[8:]))
+	if length > len(data) {
+		return nil, nil, errors.New("glb file is truncated")
+	}
+
+	for offset := 12; offset+8 <= length; {
+		chunkLength := int(binary.LittleEndian.Uint32(data[offset:]))
+		chunkType := binary.LittleEndian.Uint32(data[offset+4:])
+		start, end := offset+8, offset+8+chunkLength
+		if end > length {
+			return nil, nil, errors.New("glb chunk is truncated")
+		}
+
+		switch chunkType {
+		case glbChunkJSON:
+			jsonChunk = data[start:end]
+		case glbChunkBIN:
+			binChunk = data[start:end]
+		}
+		offset = end
+	}
+
+	if jsonChunk == nil {
+		return nil, nil, errors.New("glb file has no json chunk")
+	}
+	return jsonChunk, binChunk, nil
+}
+
+func (doc *GLTFDocument) readURI(uri string) ([]byte, error) {
+	if strings.HasPrefix(uri, "data:") {
+		comma := strings.IndexByte(uri, ',')
+		if comma < 0 || !strings.HasSuffix(uri[:comma], ";base64") {
+			return nil, errors.New("only base64 data uris are supported")
+		}
+		return base64.StdEncoding.DecodeString(uri[comma+1:])
+	}
+
+	name, err := url.PathUnescape(uri)
+	if err != nil {
+		return nil, fmt.Errorf("invalid uri %q: %w", uri, err)
+	}
+	return os.ReadFile(filepath.Join(doc.dir, filepath.FromSlash(name)))
+}
+
+func (doc *GLTFDocument) bufferView(index int) ([]byte, gltfBufferView, error) {
+	if index < 0 || index >= len(doc.root.BufferViews) {
+		return nil, gltfBufferView{}, fmt.Errorf("buffer view %d out of range", index)
+	}
+	view := doc.root.BufferViews[index]
+	if view.Buffer < 0 || view.Buffer >= len(doc.buffers) {
+		return nil, view, fmt.Errorf("buffer %d out of range", view.Buffer)
+	}
+	buf := doc.buffers[view.Buffer]
+	if view.ByteOffset+view.ByteLength > len(buf) {
+		return nil, view, fmt.Errorf("buffer view %d exceeds its buffer", index)
+	}
+	return buf[view.ByteOffset : view.ByteOffset+view.ByteLength], view, nil
+}
+
+// ReadAccessor returns the elements of an accessor as float32 values, along with
+// the number of components per element. Normalized integers are mapped to [0, 1]
+// or [-1, 1] and sparse substitutions are applied.
+func (doc *GLTFDocument) ReadAccessor(index int) ([]float32, int, error) {
+	return readAccessor(doc, index, readComponent)
+}
+
+// readAccessor reads the components of an accessor with read, which gets the
+// bytes of one component.
+func readAccessor[T any](doc *GLTFDocument, index int, read func(b []byte, componentType uint32, normalized bool) T) ([]T, int, error) {
+	if index < 0 || index >= len(doc.root.Accessors) {
+		return nil, 0, fmt.Errorf("accessor %d out of range", index)
+	}
+	acc := doc.root.Accessors[index]
+
+	components, ok := gltfComponents[acc.Type]
+	if !ok {
+		return nil, 0, fmt.Errorf("accessor %d has unknown type %q", index, acc.Type)
+	}
+	size := componentSize(acc.ComponentType)
+	if size == 0 {
+		return nil, 0, fmt.Errorf("accessor %d has unknown component type %d", index, acc.ComponentType)
+	}
+
+	out := make([]T, acc.Count*components)
+
+	// accessors without a buffer view are all zeros, unless sparse values are given
+	if acc.BufferView != nil {
+		data, view, err := doc.bufferView(*acc.BufferView)
+		if err != nil {
+			return nil, 0, fmt.Errorf("accessor %d: %w", index, err)
+		}
+
+		stride := view.ByteStride
+		if stride == 0 {
+			stride = size * components
+		}
+		if acc.Count > 0 && acc.ByteOffset+(acc.Count-1)*stride+size*components > len(data) {
+			return nil, 0, fmt.Errorf("accessor %d exceeds its buffer view", index)
+		}
+
+		for i := 0; i < acc.Count; i++ {
+			for c := 0; c < components; c++ {
+				out[i*components+c] = read(data[acc.ByteOffset+i*stride+c*size:], acc.ComponentType, acc.Normalized)
+			}
+		}
+	}
+
+	if sparse := acc.Sparse; sparse != nil {
+		indexData, _, err := doc.bufferView(sparse.Indices.BufferView)
+		if err != nil {
+			return nil, 0, fmt.Errorf("accessor %d sparse indices: %w", index, err)
+		}
+		valueData, _, err := doc.bufferView(sparse.Values.BufferView)
+		if err != nil {
+			return nil, 0, fmt.Errorf("accessor %d sparse values: %w", index, err)
+		}
+
+		indexSize := componentSize(sparse.Indices.ComponentType)
+		for i := 0; i < sparse.Count; i++ {
+			offset := sparse.Indices.ByteOffset + i*indexSize
+			valueOffset := sparse.Values.ByteOffset + i*size*components
+			if offset+indexSize > len(indexData) || valueOffset+size*components > len(valueData) {
+				return nil, 0, fmt.Errorf("accessor %d sparse data out of range", index)
+			}
+
+			target := int(readIndex(indexData[offset:], sparse.Indices.ComponentType, false))
+			if target >= acc.Count {
+				return nil, 0, fmt.Errorf("accessor %d sparse index %d out of range", index, target)
+			}
+			for c := 0; c < components; c++ {
+				out[target*components+c] = read(valueData[valueOffset+c*size:], acc.ComponentType, acc.Normalized)
+			}
+		}
+	}
+
+	return out, components, nil
+}
+
+// ReadIndices returns the elements of a scalar unsigned integer accessor,
+// decoded without a round trip through float32.
+func (doc *GLTFDocument) ReadIndices(index int) ([]uint32, error) {
+	if index < 0 || index >= len(doc.root.Accessors) {
+		return nil, fmt.Errorf("accessor %d out of range", index)
+	}
+	switch doc.root.Accessors[index].ComponentType {
+	case gltfUnsignedByte, gltfUnsignedShort, gltfUnsignedInt:
+	default:
+		return nil, fmt.Errorf("index accessor %d has component type %d, not an unsigned integer", index, doc.root.Accessors[index].ComponentType)
+	}
+
+	indices, components, err := readAccessor(doc, index, readIndex)
+	if err != nil {
+		return nil, err
+	}
+	if components != 1 {
+		return nil, fmt.Errorf("index accessor %d is not scalar", index)
+	}
+	return indices, nil
+}
+
+func componentSize(componentType uint32) int {
+	switch componentType {
+	case gltfByte, gltfUnsignedByte:
+		return 1
+	case gltfShort, gltfUnsignedShort:
+		return 2
+	case gltfUnsignedInt, gltfFloat:
+		return 4
+	}
+	return 0
+}
+
+func readComponent(b []byte, componentType uint32, normalized bool) float32 {
+	switch componentType {
+	case gltfByte:
+		v := float32(int8(b[0]))
+		if normalized {
+			return max(v/127, -1)
+		}
+		return v
+	case gltfUnsignedByte:
+		v := float32(b[0])
+		if normalized {
+			return v / 255
+		}
+		return v
+	case gltfShort:
+		v := float32(int16(binary.LittleEndian.Uint16(b)))
+		if normalized {
+			return max(v/32767, -1)
+		}
+		return v
+	case gltfUnsignedShort:
+		v := float32(binary.LittleEndian.Uint16(b))
+		if normalized {
+			return v / 65535
+		}
+		return v
+	case gltfUnsignedInt:
+		return float32(binary.LittleEndian.Uint32(b))
+	case gltfFloat:
+		return math.Float32frombits(binary.LittleEndian.Uint32(b))
+	}
+	return 0
+}
+
+// readIndex reads an unsigned integer component, also used for sparse indices.
+func readIndex(b []byte, componentType uint32, _ bool) uint32 {
+	switch componentType {
+	case gltfUnsignedByte:
+		return uint32(b[0])
+	case gltfUnsignedShort:
+		return uint32(binary.LittleEndian.Uint16(b))
+	case gltfUnsignedInt:
+		return binary.LittleEndian.Uint32(b)
+	}
+	return 0
+}
+
+// PrimitiveVertices interleaves the attributes of a mesh primitive into the
+// default Vertex layout. Missing colors default to baseColor.
+func (doc *GLTFDocument) PrimitiveVertices(mesh, primitive int, baseColor [3]float32) ([]Vertex, []uint32, error) {
+	if mesh < 0 || mesh >= len(doc.root.Meshes) || primitive < 0 || primitive >= len(doc.root.Meshes[mesh].Primitives) {
+		return nil, nil, fmt.Errorf("mesh %d primitive %d out of range", mesh, primitive)
+	}
+	prim := doc.root.Meshes[mesh].Primitives[primitive]
+
+	posIndex, ok := prim.Attributes["POSITION"]
+	if !ok {
+		return nil, nil, fmt.Errorf("mesh %d primitive %d has no POSITION attribute", mesh, primitive)
+	}
+	positions, _, err := doc.ReadAccessor(posIndex)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	vertices := make([]Vertex, len(positions)/3)
+	for i := range vertices {
+		copy(vertices[i].Position[:], positions[i*3:])
+		vertices[i].Color = baseColor
+	}
+
+	attribute := func(name string, apply func(i int, values []float32)) error {
+		index, ok := prim.Attributes[name]
+		if !ok {
+			return nil
+		}
+		values, components, err := doc.ReadAccessor(index)
+		if err != nil {
+			return err
+		}
+		if len(values)/components != len(vertices) {
+			return fmt.Errorf("attribute %s has %d elements, expected %d", name, len(values)/components, len(vertices))
+		}
+		for i := range vertices {
+			apply(i, values[i*components:(i+1)*components])
+		}
+		return nil
+	}
+
+	if err := attribute("NORMAL", func(i int, v []float32) { copy(vertices[i].Normal[:], v) }); err != nil {
+		return nil, nil, err
+	}
+	if err := attribute("TEXCOORD_0", func(i int, v []float32) { copy(vertices[i].UV[:], v) }); err != nil {
+		return nil, nil, err
+	}
+	if err := attribute("COLOR_0", func(i int, v []float32) { copy(vertices[i].Color[:], v) }); err != nil {
+		return nil, nil, err
+	}
+
+	var indices []uint32
+	if prim.Indices != nil {
+		if indices, err = doc.ReadIndices(*prim.Indices); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return vertices, indices, nil
+}
+
+// GLTFModel is the result of importing a glTF file.
+// Objects are indexed like the nodes of the file and Roots holds the nodes of the
+// imported scene. Cameras are placed using the world transform of their nodes.
+type GLTFModel struct {
+	Roots     []*Object
+	Objects   []*Object
+	Textures  []*Texture
+	Materials []*Material
+	Cameras   []Camera
+}
+
+// LoadGLTF imports the default scene of a .gltf or .glb file, building meshes,
+// textures, the node hierarchy and cameras.
+func LoadGLTF(path string) (*GLTFModel, error) {
+	doc, err := OpenGLTF(path)
+	if err != nil {
+		return nil, err
+	}
+
+	model, err := doc.Import()
+	if err != nil {
+		return nil, fmt.Errorf("failed to import gltf file %s: %w", path, err)
+	}
+	return model, nil
+}
+
+// Import uploads the document's default scene to the GPU.
+func (doc *GLTFDocument) Import() (*GLTFModel, error) {
+	if err := doc.checkHierarchy(); err != nil {
+		return nil, err
+	}
+	model := &GLTFModel{}
+	fail := func(err error) (*GLTFModel, error) {
+		model.Delete()
+		return nil, err
+	}
+
+	textures := make([]*Texture, len(doc.root.Textures))
+	for i := range doc.root.Textures {
+		tex, err := doc.loadTexture(i)
+		if err != nil {
+			return fail(fmt.Errorf("texture %d: %w", i, err))
+		}
+		textures[i] = tex
+		model.Textures = append(model.Textures, tex)
+	}
+
+	for i := range doc.root.Materials {
+		mat, err := doc.material(i, textures)
+		if err != nil {
+			return fail(fmt.Errorf("material %d: %w", i, err))
+		}
+		model.Materials = append(model.Materials, mat)
+	}
+
+	model.Objects = make([]*Object, len(doc.root.Nodes))
+	for i, node := range doc.root.Nodes {
+		obj, err := doc.nodeObject(i, node, model.Materials)
+		if err != nil {
+			return fail(fmt.Errorf("node %d: %w", i, err))
+		}
+		model.Objects[i] = obj
+	}
+
+	isChild := make([]bool, len(doc.root.Nodes))
+	for i, node := range doc.root.Nodes {
+		for _, child := range node.Children {
+			model.Objects[i].AddChild(model.Objects[child])
+			isChild[child] = true
+		}
+	}
+
+	switch {
+	case len(doc.root.Scenes) > 0:
+		scene := 0
+		if doc.root.Scene != nil {
+			scene = *doc.root.Scene
+		}
+		if scene < 0 || scene >= len(doc.root.Scenes) {
+			return fail(fmt.Errorf("scene %d out of range", scene))
+		}
+		for _, n := range doc.root.Scenes[scene].Nodes {
+			if n < 0 || n >= len(model.Objects) {
+				return fail(fmt.Errorf("scene %d has out of range node %d", scene, n))
+			}
+			model.Roots = append(model.Roots, model.Objects[n])
+		}
+	default:
+		for i, obj := range model.Objects {
+			if !isChild[i] {
+				model.Roots = append(model.Roots, obj)
+			}
+		}
+	}
+
+	doc.collectCameras(model)
+
+	return model, nil
+}
+
+// checkHierarchy makes sure the node children form a forest, as glTF requires:
+// children in range, at most one parent per node and no cycles. Building the
+// objects of an invalid hierarchy would fail in Object.AddChild.
+func (doc *GLTFDocument) checkHierarchy() error {
+	parents := make([]int, len(doc.root.Nodes))
+	for i := range parents {
+		parents[i] = -1
+	}
+	for i, node := range doc.root.Nodes {
+		for _, child := range node.Children {
+			if child < 0 || child >= len(doc.root.Nodes) {
+				return fmt.Errorf("node %d has out of range child %d", i, child)
+			}
+			if parents[child] >= 0 {
+				return fmt.Errorf("node %d is a child of both node %d and node %d", child, parents[child], i)
+			}
+			parents[child] = i
+		}
+	}
+
+	for i := range parents {
+		// a path leading into a cycle without i is cut off, the cycle is
+		// reported from one of its own nodes
+		for n, steps := parents[i], 0; n >= 0 && steps < len(parents); n, steps = parents[n], steps+1 {
+			if n == i {
+				return fmt.Errorf("node %d is its own ancestor", i)
+			}
+		}
+	}
+	return nil
+}
+
+// Delete frees the meshes, textures and materials of the model.
+func (m *GLTFModel) Delete() {
+	for _, obj := range m.Objects {
+		// children are deleted with their parent
+		if obj != nil && obj.Parent == nil {
+			obj.Delete()
+		}
+	}
+	for _, mat := range m.Materials {
+		mat.Delete()
+	}
+	for _, tex := range m.Textures {
+		tex.Delete()
+	}
+}
+
+// AddToScene adds the imported root objects to the scene. If the scene still
+// uses the DefaultCamera and the file has cameras, the first one becomes active.
+func (m *GLTFModel) AddToScene(scene *Scene) {
+	for _, root := range m.Roots {
+		scene.AddObject(root)
+	}
+	if _, ok := scene.Camera.(DefaultCamera); ok && len(m.Cameras) > 0 {
+		scene.Camera = m.Cameras[0]
+	}
+}
+
+func (doc *GLTFDocument) nodeObject(index int, node gltfNode, materials []*Material) (*Object, error) {
+	name := node.Name
+	if name == "" {
+		name = fmt.Sprintf("node%d", index)
+	}
+
+	var obj *Object
+	if node.Mesh == nil {
+		obj = NewObject(name, nil)
+	} else {
+		meshIndex := *node.Mesh
+		if meshIndex < 0 || meshIndex >= len(doc.root.Meshes) {
+			return nil, fmt.Errorf("mesh %d out of range", meshIndex)
+		}
+
+		prims := doc.root.Meshes[meshIndex].Primitives
+		parts := make([]*Object, len(prims))
+		for p := range prims {
+			part, err := doc.primitiveObject(meshIndex, p, materials)
+			if err != nil {
+				for _, part := range parts[:p] {
+					part.Delete()
+				}
+				return nil, err
+			}
+			part.Name = fmt.Sprintf("%s.%d", name, p)
+			parts[p] = part
+		}
+
+		// a single primitive becomes the node itself, several become its children
+		if len(parts) == 1 {
+			obj = parts[0]
+			obj.Name = name
+		} else {
+			obj = NewObject(name, nil)
+			for _, part := range parts {
+				obj.AddChild(part)
+			}
+		}
+	}
+
+	transform := doc.nodeTransform(node)
+	obj.Transform = &transform
+	return obj, nil
+}
+
+func (doc *GLTFDocument) nodeTransform(node gltfNode) [16]float32 {
+	if node.Matrix != nil {
+		return *node.Matrix
+	}
+
+	t, r, s := [3]float32{}, [4]float32{0, 0, 0, 1}, [3]float32{1, 1, 1}
+	if node.Translation != nil {
+		t = *node.Translation
+	}
+	if node.Rotation != nil {
+		r = *node.Rotation
+	}
+	if node.Scale != nil {
+		s = *node.Scale
+	}
+	return compose(t, r, s)
+}
+
+func (doc *GLTFDocument) primitiveObject(mesh, primitive int, materials []*Material) (*Object, error) {
+	prim := doc.root.Meshes[mesh].Primitives[primitive]
+
+	vertices, indices, err := doc.PrimitiveVertices(mesh, primitive, [3]float32{1, 1, 1})
+	if err != nil {
+		return nil, err
+	}
+
+	mode := DrawTriangles
+	if prim.Mode != nil {
+		mode = DrawMode(*prim.Mode) // glTF modes use the OpenGL enum values
+	}
+
+	if prim.Material != nil && (*prim.Material < 0 || *prim.Material >= len(materials)) {
+		return nil, fmt.Errorf("material %d out of range", *prim.Material)
+	}
+
+	obj := NewObject("", NewMesh(vertices, indices, mode))
+	if prim.Material != nil {
+		obj.Material = materials[*prim.Material]
+	}
+	return obj, nil
+}
+
+// material converts a glTF PBR material to the Blinn-Phong default material,
+// keeping its base color, normal and emissive maps.
+func (doc *GLTFDocument) material(index int, textures []*Texture) (*Material, error) {
+	src := doc.root.Materials[index]
+
+	mat, err := NewDefaultMaterial(src.Name).Unwrap()
+	if err != nil {
+		return nil, err
+	}
+
+	texture := func(info *gltfTextureInfo) (*Texture, error) {
+		if info.Index < 0 || info.Index >= len(textures) {
+			return nil, fmt.Errorf("texture %d out of range", info.Index)
+		}
+		return textures[info.Index], nil
+	}
+
+	slots := map[TextureSlot]*gltfTextureInfo{
+		SlotNormal:   src.NormalTexture,
+		SlotEmissive: src.EmissiveTexture,
+	}
+
+	if pbr := src.PbrMetallicRoughness; pbr != nil {
+		if f := pbr.BaseColorFactor; f != nil {
+			mat.SetColor("uDiffuseColor", vec3ToColor([3]float32{f[0], f[1], f[2]}, f[3]))
+		}
+		slots[SlotDiffuse] = pbr.BaseColorTexture
+	}
+
+	for slot, info := range slots {
+		if info == nil {
+			continue
+		}
+		tex, err := texture(info)
+		if err != nil {
+			return nil, err
+		}
+		mat.SetTexture(slot, tex)
+	}
+
+	// glTF defaults the emissive factor to black, which turns the emissive map off
+	if src.EmissiveFactor != nil {
+		mat.SetColor("uEmissiveColor", vec3ToColor(*src.EmissiveFactor, 1))
+	}
+
+	if src.AlphaMode == "BLEND" {
+		mat.State.Blend = BlendAlpha
+	}
+	if !src.DoubleSided {
+		mat.State.Cull = CullBack
+	}
+
+	return mat, nil
+}
+
+func (doc *GLTFDocument) loadTexture(index int) (*Texture, error) {
+	texture := doc.root.Textures[index]
+	if texture.Source == nil || *texture.Source < 0 || *texture.Source >= len(doc.root.Images) {
+		return nil, errors.New("texture has no valid image source")
+	}
+	img := doc.root.Images[*texture.Source]
+
+	var data []byte
+	var err error
+	if img.BufferView != nil {
+		data, _, err = doc.bufferView(*img.BufferView)
+	} else {
+		data, err = doc.readURI(img.URI)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load image %d: %w", *texture.Source, err)
+	}
+
+	decoded, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image %d (format: %s): %w", *texture.Source, format, err)
+	}
+
+	params := DefaultTextureParameters()
+	if texture.Sampler != nil && *texture.Sampler >= 0 && *texture.Sampler < len(doc.root.Samplers) {
+		sampler := doc.root.Samplers[*texture.Sampler]
+		// glTF samplers use the OpenGL enum values, zero means unset
+		params.WrappingS = TextureWrapping(sampler.WrapS)
+		params.WrappingT = TextureWrapping(sampler.WrapT)
+		params.FilteringMin = TextureFiltering(sampler.MinFilter)
+		params.FilteringMag = TextureFiltering(sampler.MagFilter)
+	}
+
+	name := img.Name
+	if name == "" {
+		name = img.URI
+	}
+	if name == "" || strings.HasPrefix(name, "data:") {
+		name = fmt.Sprintf("image%d", *texture.Source)
+	}
+
+	tex, err := NewTexture(decoded, name, params)
+	if err != nil {
+		return nil, err
+	}
+	return &tex, nil
+}
+
+// collectCameras adds the cameras of the imported scene in traversal order.
+func (doc *GLTFDocument) collectCameras(model *GLTFModel) {
+	nodeCameras := map[*Object]int{}
+	for i, node := range doc.root.Nodes {
+		if node.Camera != nil && *node.Camera >= 0 && *node.Camera < len(doc.root.Cameras) {
+			nodeCameras[model.Objects[i]] = *node.Camera
+		}
+	}
+
+	for _, root := range model.Roots {
+		root.Traverse(func(obj *Object) bool {
+			if index, ok := nodeCameras[obj]; ok {
+				model.Cameras = append(model.Cameras, gltfCameraAt(doc.root.Cameras[index], obj.WorldMatrix()))
+			}
+			return true
+		})
+	}
+}
+
+// gltfCameraAt converts a glTF camera, which looks down its local -Z axis.
+func gltfCameraAt(cam gltfCamera, world [16]float32) Camera {
+	position := transformPoint(world, madar.Vector3{})
+	forward := normalize(transformDirection(world, madar.Vector3{X: 0, Y: 0, Z: -1}))
+	up := normalize(transformDirection(world, madar.Vector3{X: 0, Y: 1, Z: 0}))
+	target := add(position, forward)
+
+	if cam.Type == "orthographic" && cam.Orthographic != nil {
+		o := cam.Orthographic
+		c := NewOrthographicCamera(position, target, -o.Xmag, o.Xmag, -o.Ymag, o.Ymag, o.Znear, o.Zfar)
+		c.Up = up
+		return c
+	}
+
+	aspect, fov, near, far := float32(1), float32(60), float32(0.1), float32(1000)
+	if p := cam.Perspective; p != nil {
+		if p.AspectRatio > 0 {
+			aspect = p.AspectRatio
+		}
+		fov = degrees(p.Yfov)
+		near = p.Znear
+		if p.Zfar > 0 {
+			far = p.Zfar
+		}
+	}
+
+	c := NewPerspectiveCamera(position, target, fov, aspect, near, far)
+	c.Up = up
+	return c
+}
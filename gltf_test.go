@@ -0,0 +1,217 @@
+package noor
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// testGLTFBuffer holds the data of the accessors of testGLTFJSON, at the
+// offsets of its buffer views.
+func testGLTFBuffer() []byte {
+	var buf []byte
+	le := binary.LittleEndian
+	buf, _ = binary.Append(buf, le, []float32{0, 0, 0, 1, 0, 0, 0, 1, 0}) // 0: positions
+	buf, _ = binary.Append(buf, le, []uint32{0, 1, 1<<24 + 1})            // 36: uint indices
+	buf = append(buf, 7, 0, 0, 0, 8, 0, 0, 0, 9, 0, 0, 0)                 // 48: strided byte indices
+	buf = append(buf, 255, 0, 0, 0)                                       // 60: normalized bytes
+	buf = append(buf, 2, 0, 0, 0)                                         // 64: sparse index
+	buf, _ = binary.Append(buf, le, float32(5))                           // 68: sparse value
+	buf, _ = binary.Append(buf, le, []uint16{0, 1, 2, 0})                 // 72: short indices
+	return buf
+}
+
+const testGLTFJSON = `{
+	"asset": {"version": "2.0"},
+	"buffers": [{%s"byteLength": 80}],
+	"bufferViews": [
+		{"buffer": 0, "byteOffset": 0, "byteLength": 36},
+		{"buffer": 0, "byteOffset": 36, "byteLength": 12},
+		{"buffer": 0, "byteOffset": 48, "byteLength": 12, "byteStride": 4},
+		{"buffer": 0, "byteOffset": 60, "byteLength": 2},
+		{"buffer": 0, "byteOffset": 64, "byteLength": 1},
+		{"buffer": 0, "byteOffset": 68, "byteLength": 4},
+		{"buffer": 0, "byteOffset": 72, "byteLength": 6}
+	],
+	"accessors": [
+		{"bufferView": 0, "componentType": 5126, "count": 3, "type": "VEC3"},
+		{"bufferView": 1, "componentType": 5125, "count": 3, "type": "SCALAR"},
+		{"bufferView": 2, "componentType": 5121, "count": 3, "type": "SCALAR"},
+		{"bufferView": 0, "componentType": 5126, "count": 3, "type": "SCALAR"},
+		{"bufferView": 3, "componentType": 5121, "normalized": true, "count": 1, "type": "VEC2"},
+		{"componentType": 5126, "count": 4, "type": "SCALAR", "sparse": {
+			"count": 1,
+			"indices": {"bufferView": 4, "componentType": 5121},
+			"values": {"bufferView": 5}
+		}},
+		{"bufferView": 6, "componentType": 5123, "count": 3, "type": "SCALAR"}
+	],
+	"meshes": [{"primitives": [{"attributes": {"POSITION": 0}, "indices": 6}]}]
+}`
+
+// glb packs a json and a binary chunk into a .glb container.
+func glb(json string, bin []byte) []byte {
+	pad := func(chunk []byte, with byte) []byte {
+		for len(chunk)%4 != 0 {
+			chunk = append(chunk, with)
+		}
+		return chunk
+	}
+	jsonChunk, binChunk := pad([]byte(json), ' '), pad(bin, 0)
+
+	le := binary.LittleEndian
+	data := le.AppendUint32(nil, glbMagic)
+	data = le.AppendUint32(data, 2)
+	data = le.AppendUint32(data, uint32(12+8+len(jsonChunk)+8+len(binChunk)))
+	data = le.AppendUint32(data, uint32(len(jsonChunk)))
+	data = le.AppendUint32(data, glbChunkJSON)
+	data = append(data, jsonChunk...)
+	data = le.AppendUint32(data, uint32(len(binChunk)))
+	data = le.AppendUint32(data, glbChunkBIN)
+	return append(data, binChunk...)
+}
+
+func parseTestGLTF(t *testing.T) *GLTFDocument {
+	t.Helper()
+	doc, err := ParseGLTF(glb(fmt.Sprintf(testGLTFJSON, ""), testGLTFBuffer()), ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+func TestParseGLTFDataURI(t *testing.T) {
+	uri := `"uri": "data:application/octet-stream;base64,` + base64.StdEncoding.EncodeToString(testGLTFBuffer()) + `", `
+	doc, err := ParseGLTF([]byte(fmt.Sprintf(testGLTFJSON, uri)), ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	values, components, err := doc.ReadAccessor(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if components != 3 || !slices.Equal(values, []float32{0, 0, 0, 1, 0, 0, 0, 1, 0}) {
+		t.Errorf("got %d components %v", components, values)
+	}
+}
+
+func TestReadIndices(t *testing.T) {
+	doc := parseTestGLTF(t)
+
+	tests := []struct {
+		name     string
+		accessor int
+		want     []uint32
+	}{
+		// 1<<24 + 1 has no exact float32 representation
+		{"unsigned int", 1, []uint32{0, 1, 1<<24 + 1}},
+		{"strided unsigned byte", 2, []uint32{7, 8, 9}},
+		{"unsigned short", 6, []uint32{0, 1, 2}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			indices, err := doc.ReadIndices(test.accessor)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(indices, test.want) {
+				t.Errorf("got %v, want %v", indices, test.want)
+			}
+		})
+	}
+
+	if _, err := doc.ReadIndices(3); err == nil {
+		t.Error("float indices were accepted")
+	}
+	if _, err := doc.ReadIndices(42); err == nil {
+		t.Error("an out of range accessor was accepted")
+	}
+}
+
+func TestReadAccessor(t *testing.T) {
+	doc := parseTestGLTF(t)
+
+	normalized, components, err := doc.ReadAccessor(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if components != 2 || !slices.Equal(normalized, []float32{1, 0}) {
+		t.Errorf("normalized bytes read as %d components %v, want 2 components [1 0]", components, normalized)
+	}
+
+	sparse, _, err := doc.ReadAccessor(5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []float32{0, 0, 5, 0}; !slices.Equal(sparse, want) {
+		t.Errorf("sparse accessor read as %v, want %v", sparse, want)
+	}
+}
+
+func TestPrimitiveVertices(t *testing.T) {
+	doc := parseTestGLTF(t)
+
+	vertices, indices, err := doc.PrimitiveVertices(0, 0, [3]float32{1, 0.5, 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vertices) != 3 {
+		t.Fatalf("got %d vertices, want 3", len(vertices))
+	}
+	if vertices[1].Position != [3]float32{1, 0, 0} || vertices[1].Color != [3]float32{1, 0.5, 0} {
+		t.Errorf("vertex 1 is %+v", vertices[1])
+	}
+	if !slices.Equal(indices, []uint32{0, 1, 2}) {
+		t.Errorf("got indices %v", indices)
+	}
+
+	if _, _, err := doc.PrimitiveVertices(1, 0, [3]float32{}); err == nil {
+		t.Error("an out of range mesh was accepted")
+	}
+}
+
+func TestImportRejectsInvalidHierarchies(t *testing.T) {
+	tests := []struct {
+		name, nodes, want string
+	}{
+		{"self parent", `[{"children": [0]}]`, "node 0 is its own ancestor"},
+		{"cycle", `[{"children": [1]}, {"children": [2]}, {"children": [0]}]`, "is its own ancestor"},
+		{"two parents", `[{"children": [2]}, {"children": [2]}, {}]`, "node 2 is a child of both node 0 and node 1"},
+		{"out of range child", `[{"children": [3]}]`, "node 0 has out of range child 3"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			doc, err := ParseGLTF([]byte(`{"nodes": `+test.nodes+`}`), ".")
+			if err != nil {
+				t.Fatal(err)
+			}
+			// the hierarchy is checked before anything is uploaded, so no
+			// GL context is needed
+			_, err = doc.Import()
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("got error %v, want %q", err, test.want)
+			}
+		})
+	}
+
+	doc, err := ParseGLTF([]byte(`{"nodes": [{"children": [1, 2]}, {"children": [3]}, {}, {}]}`), ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := doc.checkHierarchy(); err != nil {
+		t.Errorf("a valid tree was rejected: %v", err)
+	}
+}
+
+func TestParseGLBErrors(t *testing.T) {
+	data := glb(fmt.Sprintf(testGLTFJSON, ""), testGLTFBuffer())
+	if _, err := ParseGLTF(data[:len(data)-8], "."); err == nil {
+		t.Error("a truncated glb was accepted")
+	}
+	if _, err := ParseGLTF([]byte(fmt.Sprintf(testGLTFJSON, "")), "."); err == nil {
+		t.Error("a buffer without uri outside of a glb was accepted")
+	}
+}
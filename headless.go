@@ -0,0 +1,84 @@
+package noor
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+)
+
+// NewHeadless creates a context without a visible window, to render scenes
+// into images with RenderImage on build machines and in tests. The scene is
+// drawn into an offscreen framebuffer of width by height.
+//
+// The context is not truly headless: it belongs to a hidden GLFW window, so a
+// display server is still required, and NewHeadless fails without one. There
+// is no surfaceless EGL or OSMesa path; on machines without a display run
+// under a virtual server such as xvfb-run. Without a GPU, Mesa's software
+// rasterizer is used when LIBGL_ALWAYS_SOFTWARE=1 is set.
+func NewHeadless(width, height int) Result[Noor] {
+	result := create(width, height, "noor", color.Black, false)
+	if result.IsErr() {
+		return Err[Noor](fmt.Errorf("failed to create headless context: %w", result.Err))
+	}
+	n := result.Ok
+
+	target, err := NewFramebuffer(int32(width), int32(height), DefaultFramebufferOptions())
+	if err != nil {
+		n.Close()
+		return Err[Noor](fmt.Errorf("failed to create headless target: %w", err))
+	}
+	n.target = target
+	n.headless = true
+
+	if cam, ok := n.Scene.Camera.(AspectCamera); ok && height > 0 {
+		cam.SetAspect(float32(width) / float32(height))
+	}
+	return Ok(n)
+}
+
+// SetImageSize changes the size of the images RenderImage returns in headless mode.
+func (n *Noor) SetImageSize(width, height int) error {
+	if n.target == nil {
+		return fmt.Errorf("no render target, create the context with NewHeadless")
+	}
+	if err := n.target.Resize(int32(width), int32(height)); err != nil {
+		return fmt.Errorf("failed to resize render target: %w", err)
+	}
+	if cam, ok := n.Scene.Camera.(AspectCamera); ok && height > 0 {
+		cam.SetAspect(float32(width) / float32(height))
+	}
+	return nil
+}
+
+// RenderImage renders the scene offscreen, through n.Post when set, and
+// returns the result top row first. Outside of headless mode the image has
+// the size of the window.
+func (n *Noor) RenderImage() (*image.RGBA, error) {
+	if n.target == nil {
+		width, height := n.Window.GetFramebufferSize()
+		target, err := NewFramebuffer(int32(width), int32(height), DefaultFramebufferOptions())
+		if err != nil {
+			return nil, fmt.Errorf("failed to create render target: %w", err)
+		}
+		n.target = target
+	} else if !n.headless {
+		width, height := n.Window.GetFramebufferSize()
+		if err := n.target.Resize(int32(width), int32(height)); err != nil {
+			return nil, fmt.Errorf("failed to resize render target: %w", err)
+		}
+	}
+
+	if n.Post != nil {
+		if err := n.Post.Resize(n.target.Width, n.target.Height); err != nil {
+			return nil, fmt.Errorf("failed to resize post processing buffers: %w", err)
+		}
+		n.Post.Output = n.target
+		n.Post.Render(n.Scene)
+		n.Post.Output = nil
+		BindDefaultFramebuffer(n.target.Width, n.target.Height)
+	} else {
+		n.target.Render(n.Scene)
+	}
+
+	return n.target.Image(0)
+}
@@ -0,0 +1,90 @@
+package noor
+
+import "github.com/go-gl/glfw/v3.3/glfw"
+
+// Input tracks per-frame mouse movement and scrolling for a window, on top of
+// the polled key and button state GLFW already provides.
+type Input struct {
+	window *glfw.Window
+
+	cursorX, cursorY float64
+	deltaX, deltaY   float64
+	hasCursor        bool
+
+	scrollX, scrollY               float64
+	pendingScrollX, pendingScrollY float64
+
+	captured bool
+}
+
+func newInput(window *glfw.Window) *Input {
+	in := &Input{window: window}
+	window.SetScrollCallback(func(w *glfw.Window, xoff, yoff float64) {
+		in.pendingScrollX += xoff
+		in.pendingScrollY += yoff
+	})
+	return in
+}
+
+// update is called once per frame by Noor.Loop, after events have been polled.
+func (in *Input) update() {
+	x, y := in.window.GetCursorPos()
+	if in.hasCursor {
+		in.deltaX = x - in.cursorX
+		in.deltaY = y - in.cursorY
+	}
+	in.cursorX, in.cursorY = x, y
+	in.hasCursor = true
+
+	in.scrollX, in.scrollY = in.pendingScrollX, in.pendingScrollY
+	in.pendingScrollX, in.pendingScrollY = 0, 0
+}
+
+func (in *Input) KeyDown(key glfw.Key) bool {
+	return in.window.GetKey(key) == glfw.Press
+}
+
+func (in *Input) MouseDown(button glfw.MouseButton) bool {
+	return in.window.GetMouseButton(button) == glfw.Press
+}
+
+// CursorPos returns the cursor position in screen coordinates.
+func (in *Input) CursorPos() (x, y float32) {
+	return float32(in.cursorX), float32(in.cursorY)
+}
+
+// CursorDelta returns how far the cursor moved since the previous frame.
+func (in *Input) CursorDelta() (dx, dy float32) {
+	return float32(in.deltaX), float32(in.deltaY)
+}
+
+// Scroll returns the scroll offset accumulated during the previous frame.
+func (in *Input) Scroll() (x, y float32) {
+	return float32(in.scrollX), float32(in.scrollY)
+}
+
+// CaptureCursor hides the cursor and locks it to the window, giving unbounded
+// relative motion for mouse look. Passing false restores the normal cursor.
+func (in *Input) CaptureCursor(capture bool) {
+	if capture == in.captured {
+		return
+	}
+	in.captured = capture
+
+	if capture {
+		in.window.SetInputMode(glfw.CursorMode, glfw.CursorDisabled)
+		if glfw.RawMouseMotionSupported() {
+			in.window.SetInputMode(glfw.RawMouseMotion, glfw.True)
+		}
+	} else {
+		in.window.SetInputMode(glfw.CursorMode, glfw.CursorNormal)
+	}
+
+	// the cursor jumps when its mode changes, don't report that as movement
+	in.hasCursor = false
+	in.deltaX, in.deltaY = 0, 0
+}
+
+func (in *Input) CursorCaptured() bool {
+	return in.captured
+}
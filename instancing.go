@@ -0,0 +1,92 @@
+package noor
+
+import (
+	"slices"
+	"unsafe"
+
+	"github.com/go-gl/gl/v4.6-core/gl"
+)
+
+// InstanceData is the per instance input of the default shader.
+type InstanceData struct {
+	Model [16]float32 // column-major, applied after the object's own matrix
+	Color [4]float32  // multiplies the vertex color
+}
+
+// NewInstance creates an instance with a model matrix and a white color.
+func NewInstance(model [16]float32) InstanceData {
+	return InstanceData{Model: model, Color: [4]float32{1, 1, 1, 1}}
+}
+
+// InstanceLayout is the layout of InstanceData. A mat4 attribute takes four
+// consecutive locations, one per column.
+var InstanceLayout = VertexLayout{
+	Stride: 80,
+	Attributes: []VertexAttribute{
+		{Name: "aInstanceModel", Location: 4, Components: 4, Type: AttribFloat, Offset: 0, Divisor: 1},
+		{Name: "aInstanceModel", Location: 5, Components: 4, Type: AttribFloat, Offset: 16, Divisor: 1},
+		{Name: "aInstanceModel", Location: 6, Components: 4, Type: AttribFloat, Offset: 32, Divisor: 1},
+		{Name: "aInstanceModel", Location: 7, Components: 4, Type: AttribFloat, Offset: 48, Divisor: 1},
+		{Name: "aInstanceColor", Location: 8, Components: 4, Type: AttribFloat, Offset: 64, Divisor: 1},
+	},
+}
+
+// SetInstances uploads per instance data to the mesh's instance buffer, growing
+// it when needed, and wires it to the attributes of layout. Attributes with a
+// zero Divisor advance once per instance. Custom shaders can use any struct
+// with a matching layout, see LayoutOf.
+func SetInstances[T any](m *Mesh, layout VertexLayout, instances []T) {
+	if layout.Stride == 0 {
+		layout.Stride = int32(unsafe.Sizeof(*new(T)))
+	}
+	size := len(instances) * int(layout.Stride)
+	if size == 0 {
+		return
+	}
+
+	gl.BindVertexArray(m.VAO)
+	if m.instanceVBO == 0 {
+		gl.GenBuffers(1, &m.instanceVBO)
+	}
+	gl.BindBuffer(gl.ARRAY_BUFFER, m.instanceVBO)
+
+	if size > m.instanceCapacity {
+		m.instanceCapacity = max(size, m.instanceCapacity*2)
+		gl.BufferData(gl.ARRAY_BUFFER, m.instanceCapacity, nil, gl.STREAM_DRAW)
+	}
+	gl.BufferSubData(gl.ARRAY_BUFFER, 0, size, gl.Ptr(instances))
+
+	// the attributes are shared, e.g. with InstanceLayout
+	layout.Attributes = slices.Clone(layout.Attributes)
+	for i := range layout.Attributes {
+		if layout.Attributes[i].Divisor == 0 {
+			layout.Attributes[i].Divisor = 1
+		}
+	}
+	layout.apply()
+
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+	gl.BindVertexArray(0)
+}
+
+// DrawInstanced draws the mesh count times, reading the instance buffer set
+// with SetInstances.
+func (m *Mesh) DrawInstanced(count int32) {
+	gl.BindVertexArray(m.VAO)
+	m.drawInstancedCall(count)
+	gl.BindVertexArray(0)
+}
+
+func (m *Mesh) drawInstancedCall(count int32) {
+	if m.DrawElements {
+		gl.DrawElementsInstancedBaseVertex(uint32(m.DrawMode), m.Count, gl.UNSIGNED_INT, nil, count, m.First)
+	} else {
+		gl.DrawArraysInstanced(uint32(m.DrawMode), m.First, m.Count, count)
+	}
+}
+
+func setInstanced(sh Shader, instanced bool) {
+	if sh.HasUniform("uInstanced") {
+		sh.SetUniformBool("uInstanced", instanced)
+	}
+}
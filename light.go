@@ -0,0 +1,140 @@
+package noor
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"unsafe"
+
+	"github.com/ahmedsat/madar"
+)
+
+// MaxLights is the number of lights the default shader is compiled for.
+// Set it before creating objects, lights beyond it are ignored.
+var MaxLights = 8
+
+type LightType int32
+
+// these values match the LIGHT_* defines of the default fragment shader
+const (
+	LightDirectional LightType = iota
+	LightPoint
+	LightSpot
+)
+
+type Light struct {
+	Type LightType
+
+	Position  madar.Vector3 // point and spot lights
+	Direction madar.Vector3 // directional and spot lights, pointing away from the light
+
+	Color     color.Color
+	Intensity float32
+
+	// distance attenuation of point and spot lights: 1 / (constant + linear*d + quadratic*d²)
+	Constant  float32
+	Linear    float32
+	Quadratic float32
+
+	// spot cone angles in degrees, light fades out between the inner and outer angle
+	InnerCone float32
+	OuterCone float32
+}
+
+func NewDirectionalLight(direction madar.Vector3, c color.Color, intensity float32) *Light {
+	return &Light{
+		Type:      LightDirectional,
+		Direction: direction,
+		Color:     c,
+		Intensity: intensity,
+	}
+}
+
+// NewPointLight creates a point light with attenuation suited to a range of about 50 units.
+func NewPointLight(position madar.Vector3, c color.Color, intensity float32) *Light {
+	return &Light{
+		Type:      LightPoint,
+		Position:  position,
+		Color:     c,
+		Intensity: intensity,
+		Constant:  1,
+		Linear:    0.09,
+		Quadratic: 0.032,
+	}
+}
+
+func NewSpotLight(position, direction madar.Vector3, c color.Color, intensity, innerCone, outerCone float32) *Light {
+	return &Light{
+		Type:      LightSpot,
+		Position:  position,
+		Direction: direction,
+		Color:     c,
+		Intensity: intensity,
+		Constant:  1,
+		Linear:    0.09,
+		Quadratic: 0.032,
+		InnerCone: innerCone,
+		OuterCone: outerCone,
+	}
+}
+
+// upload sets the light as uLights[index] of the shader.
+func (l *Light) upload(sh Shader, index int) {
+	prefix := fmt.Sprintf("uLights[%d].", index)
+	r, g, b := colorToVec3(l.Color)
+
+	sh.SetUniformInt32(prefix+"type", int32(l.Type))
+	sh.SetUniformVector3(prefix+"position", l.Position)
+	sh.SetUniformVector3(prefix+"direction", l.Direction)
+	sh.SetUniformVec3(prefix+"color", r, g, b)
+	sh.SetUniformFloat32(prefix+"intensity", l.Intensity)
+	sh.SetUniformFloat32(prefix+"constant", l.Constant)
+	sh.SetUniformFloat32(prefix+"linear", l.Linear)
+	sh.SetUniformFloat32(prefix+"quadratic", l.Quadratic)
+	sh.SetUniformFloat32(prefix+"innerCutoff", float32(math.Cos(float64(radians(l.InnerCone)))))
+	sh.SetUniformFloat32(prefix+"outerCutoff", float32(math.Cos(float64(radians(l.OuterCone)))))
+}
+
+// uploadLights sets the scene lights on every shader used by its objects.
+// Shaders without a uLightCount uniform are skipped.
+func (s *Scene) uploadLights() {
+	count := min(len(s.Lights), MaxLights)
+	viewPos := cameraPosition(s.Camera)
+	ar, ag, ab := colorToVec3(s.Ambient)
+
+	uploaded := map[Shader]bool{}
+	s.Traverse(func(obj *Object) bool {
+		sh := obj.ActiveShader()
+		if obj.Mesh == nil || uploaded[sh] || !sh.HasUniform("uLightCount") {
+			return true
+		}
+		uploaded[sh] = true
+
+		sh.Activate()
+		sh.SetUniformInt32("uLightCount", int32(count))
+		sh.SetUniformVec3("uAmbient", ar, ag, ab)
+		sh.SetUniformVector3("uViewPos", viewPos)
+		for i := 0; i < count; i++ {
+			s.Lights[i].upload(sh, i)
+		}
+		return true
+	})
+}
+
+// cameraPosition extracts the eye position from a rigid view matrix.
+func cameraPosition(camera Camera) madar.Vector3 {
+	m := unsafe.Slice(camera.View(), 16)
+	return madar.Vector3{
+		X: -(m[0]*m[12] + m[1]*m[13] + m[2]*m[14]),
+		Y: -(m[4]*m[12] + m[5]*m[13] + m[6]*m[14]),
+		Z: -(m[8]*m[12] + m[9]*m[13] + m[10]*m[14]),
+	}
+}
+
+func colorToVec3(c color.Color) (r, g, b float32) {
+	if c == nil {
+		return 0, 0, 0
+	}
+	cr, cg, cb, _ := c.RGBA()
+	return float32(cr) / 0xffff, float32(cg) / 0xffff, float32(cb) / 0xffff
+}
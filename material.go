@@ -0,0 +1,210 @@
+package noor
+
+import (
+	"fmt"
+	"image/color"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/ahmedsat/madar"
+)
+
+// TextureSlot is the sampler uniform a material texture is bound to.
+type TextureSlot string
+
+const (
+	SlotDiffuse  TextureSlot = "uDiffuseMap"
+	SlotSpecular TextureSlot = "uSpecularMap"
+	SlotNormal   TextureSlot = "uNormalMap"
+	SlotEmissive TextureSlot = "uEmissiveMap"
+)
+
+// standardSlots are always reported to the shader through a uHas<Slot> bool,
+// so shaders can fall back to constant colors when a map is missing.
+var standardSlots = []TextureSlot{SlotDiffuse, SlotSpecular, SlotNormal, SlotEmissive}
+
+// hasUniform returns the name of the bool uniform telling whether the slot is bound,
+// e.g. uHasDiffuseMap for uDiffuseMap.
+func (s TextureSlot) hasUniform() string {
+	return "uHas" + string(s)[1:]
+}
+
+// Material bundles a shader with the textures, parameters and render state it
+// is drawn with. A material can be shared by any number of objects.
+type Material struct {
+	Name   string
+	Shader Shader
+
+	Textures   map[TextureSlot]*Texture
+	Parameters map[string]any
+	State      RenderState
+}
+
+// NewMaterial creates a material drawn with shader, which it retains until Delete.
+func NewMaterial(name string, shader Shader) *Material {
+	shader.Retain()
+	return &Material{
+		Name:       name,
+		Shader:     shader,
+		Textures:   map[TextureSlot]*Texture{},
+		Parameters: map[string]any{},
+		State:      DefaultRenderState(),
+	}
+}
+
+// NewDefaultMaterial creates a material using the lit default shader,
+// with a white diffuse color, a grey specular color and a shininess of 32.
+func NewDefaultMaterial(name string) Result[*Material] {
+	shader := sharedDefaultShader()
+	if shader.IsErr() {
+		return Err[*Material](shader.Err)
+	}
+
+	mat := NewMaterial(name, shader.Ok)
+	maps.Copy(mat.Parameters, defaultMaterialParameters)
+	return Ok(mat)
+}
+
+// defaultMaterialParameters are the surface parameters of the default shader.
+var defaultMaterialParameters = map[string]any{
+	"uDiffuseColor":  color.White,
+	"uSpecularColor": color.Gray{Y: 0x80},
+	"uEmissiveColor": color.Black,
+	"uShininess":     float32(32),
+}
+
+func (m *Material) SetTexture(slot TextureSlot, tex *Texture) {
+	m.Textures[slot] = tex
+}
+
+func (m *Material) SetFloat(name string, value float32) {
+	m.Parameters[name] = value
+}
+
+func (m *Material) SetInt(name string, value int32) {
+	m.Parameters[name] = value
+}
+
+func (m *Material) SetBool(name string, value bool) {
+	m.Parameters[name] = value
+}
+
+func (m *Material) SetVector(name string, value madar.Vector3) {
+	m.Parameters[name] = value
+}
+
+// SetColor sets a vec4 parameter from a color, as 0..1 RGBA with straight alpha.
+func (m *Material) SetColor(name string, value color.Color) {
+	m.Parameters[name] = value
+}
+
+// Apply activates the material's shader, binds its textures starting at texture
+// unit 0 and uploads its parameters and render state. Without a diffuse map,
+// uTexture is pointed at an empty unit. It returns the next free texture unit.
+func (m *Material) Apply() uint32 {
+	m.Shader.Activate()
+	m.State.apply()
+
+	for _, slot := range standardSlots {
+		if m.Shader.HasUniform(slot.hasUniform()) {
+			_, ok := m.Textures[slot]
+			m.Shader.SetUniformBool(slot.hasUniform(), ok)
+		}
+	}
+
+	unit := uint32(0)
+	for _, slot := range slices.Sorted(maps.Keys(m.Textures)) {
+		if tex := m.Textures[slot]; tex != nil {
+			tex.Activate(m.Shader, unit, string(slot))
+			unit++
+		}
+	}
+	if m.Textures[SlotDiffuse] == nil && m.Shader.HasUniform("uTexture") {
+		bindEmptyTexture(m.Shader, unit, "uTexture")
+		unit++
+	}
+
+	for name, value := range m.Parameters {
+		m.setParameter(name, value)
+	}
+
+	return unit
+}
+
+func (m *Material) setParameter(name string, value any) {
+	switch v := value.(type) {
+	case float32:
+		m.Shader.SetUniformFloat32(name, v)
+	case int32:
+		m.Shader.SetUniformInt32(name, v)
+	case bool:
+		m.Shader.SetUniformBool(name, v)
+	case madar.Vector3:
+		m.Shader.SetUniformVector3(name, v)
+	case color.Color:
+		// uniforms take straight alpha, RGBA() is premultiplied
+		c := color.NRGBA64Model.Convert(v).(color.NRGBA64)
+		m.Shader.SetUniformVec4(name, float32(c.R)/0xffff, float32(c.G)/0xffff, float32(c.B)/0xffff, float32(c.A)/0xffff)
+	default:
+		fmt.Fprintf(os.Stderr, "Material %s: unsupported parameter type %T for %s\n", m.Name, value, name)
+	}
+}
+
+// Validate checks the material's textures and parameters against the active
+// uniforms of its shader.
+func (m *Material) Validate() error {
+	r := m.Shader.Reflect()
+	var problems []string
+
+	for slot, tex := range m.Textures {
+		if tex == nil {
+			continue
+		}
+		if u, ok := r.Uniform(string(slot)); !ok || !u.Type.IsSampler() {
+			problems = append(problems, fmt.Sprintf("texture slot %s has no matching sampler", slot))
+		}
+	}
+	for name := range m.Parameters {
+		if _, ok := r.Uniform(name); !ok {
+			problems = append(problems, fmt.Sprintf("parameter %s has no matching uniform", name))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("material %s: %s", m.Name, strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+// Delete releases the material's shader. Textures are left alone since they may
+// be shared with other materials.
+func (m *Material) Delete() {
+	m.Shader.Release()
+}
+
+// resetMaterialUniforms marks every standard slot as unbound, points uTexture at
+// an empty unit and restores the default surface parameters, for objects drawn
+// without a material on a shader that may also be used with one. It returns the
+// next free texture unit.
+func resetMaterialUniforms(sh Shader) uint32 {
+	for _, slot := range standardSlots {
+		if sh.HasUniform(slot.hasUniform()) {
+			sh.SetUniformBool(slot.hasUniform(), false)
+		}
+	}
+
+	defaults := Material{Name: "default", Shader: sh}
+	for name, value := range defaultMaterialParameters {
+		if sh.HasUniform(name) {
+			defaults.setParameter(name, value)
+		}
+	}
+
+	if !sh.HasUniform("uTexture") {
+		return 0
+	}
+	bindEmptyTexture(sh, 0, "uTexture")
+	return 1
+}
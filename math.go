@@ -0,0 +1,134 @@
+package noor
+
+import (
+	"math"
+
+	"github.com/ahmedsat/madar"
+)
+
+// matrices are stored column-major, the layout expected by gl.UniformMatrix4fv
+// when transpose is false.
+
+func perspective(fovY, aspect, near, far float32) [16]float32 {
+	f := float32(1 / math.Tan(float64(radians(fovY))/2))
+	return [16]float32{
+		f / aspect, 0, 0, 0,
+		0, f, 0, 0,
+		0, 0, (far + near) / (near - far), -1,
+		0, 0, 2 * far * near / (near - far), 0,
+	}
+}
+
+func orthographic(left, right, bottom, top, near, far float32) [16]float32 {
+	return [16]float32{
+		2 / (right - left), 0, 0, 0,
+		0, 2 / (top - bottom), 0, 0,
+		0, 0, -2 / (far - near), 0,
+		-(right + left) / (right - left), -(top + bottom) / (top - bottom), -(far + near) / (far - near), 1,
+	}
+}
+
+func lookAt(eye, target, up madar.Vector3) [16]float32 {
+	f := normalize(sub(target, eye))
+	s := normalize(cross(f, up))
+	u := cross(s, f)
+	return [16]float32{
+		s.X, u.X, -f.X, 0,
+		s.Y, u.Y, -f.Y, 0,
+		s.Z, u.Z, -f.Z, 0,
+		-dot(s, eye), -dot(u, eye), dot(f, eye), 1,
+	}
+}
+
+func identity() [16]float32 {
+	return [16]float32{1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1}
+}
+
+func multiply(a, b [16]float32) (out [16]float32) {
+	for col := 0; col < 4; col++ {
+		for row := 0; row < 4; row++ {
+			var sum float32
+			for k := 0; k < 4; k++ {
+				sum += a[k*4+row] * b[col*4+k]
+			}
+			out[col*4+row] = sum
+		}
+	}
+	return out
+}
+
+// compose builds translation × rotation × scale, with the rotation given as
+// a unit quaternion (x, y, z, w).
+func compose(t [3]float32, q [4]float32, s [3]float32) [16]float32 {
+	x, y, z, w := q[0], q[1], q[2], q[3]
+	return [16]float32{
+		(1 - 2*(y*y+z*z)) * s[0], 2 * (x*y + z*w) * s[0], 2 * (x*z - y*w) * s[0], 0,
+		2 * (x*y - z*w) * s[1], (1 - 2*(x*x+z*z)) * s[1], 2 * (y*z + x*w) * s[1], 0,
+		2 * (x*z + y*w) * s[2], 2 * (y*z - x*w) * s[2], (1 - 2*(x*x+y*y)) * s[2], 0,
+		t[0], t[1], t[2], 1,
+	}
+}
+
+func transformPoint(m [16]float32, v madar.Vector3) madar.Vector3 {
+	return madar.Vector3{
+		X: m[0]*v.X + m[4]*v.Y + m[8]*v.Z + m[12],
+		Y: m[1]*v.X + m[5]*v.Y + m[9]*v.Z + m[13],
+		Z: m[2]*v.X + m[6]*v.Y + m[10]*v.Z + m[14],
+	}
+}
+
+func transformDirection(m [16]float32, v madar.Vector3) madar.Vector3 {
+	return madar.Vector3{
+		X: m[0]*v.X + m[4]*v.Y + m[8]*v.Z,
+		Y: m[1]*v.X + m[5]*v.Y + m[9]*v.Z,
+		Z: m[2]*v.X + m[6]*v.Y + m[10]*v.Z,
+	}
+}
+
+func radians(degrees float32) float32 {
+	return degrees * math.Pi / 180
+}
+
+func degrees(radians float32) float32 {
+	return radians * 180 / math.Pi
+}
+
+func clamp(v, lo, hi float32) float32 {
+	return max(lo, min(v, hi))
+}
+
+func add(a, b madar.Vector3) madar.Vector3 {
+	return madar.Vector3{X: a.X + b.X, Y: a.Y + b.Y, Z: a.Z + b.Z}
+}
+
+func sub(a, b madar.Vector3) madar.Vector3 {
+	return madar.Vector3{X: a.X - b.X, Y: a.Y - b.Y, Z: a.Z - b.Z}
+}
+
+func scale(v madar.Vector3, s float32) madar.Vector3 {
+	return madar.Vector3{X: v.X * s, Y: v.Y * s, Z: v.Z * s}
+}
+
+func dot(a, b madar.Vector3) float32 {
+	return a.X*b.X + a.Y*b.Y + a.Z*b.Z
+}
+
+func cross(a, b madar.Vector3) madar.Vector3 {
+	return madar.Vector3{
+		X: a.Y*b.Z - a.Z*b.Y,
+		Y: a.Z*b.X - a.X*b.Z,
+		Z: a.X*b.Y - a.Y*b.X,
+	}
+}
+
+func length(v madar.Vector3) float32 {
+	return float32(math.Sqrt(float64(dot(v, v))))
+}
+
+func normalize(v madar.Vector3) madar.Vector3 {
+	l := length(v)
+	if l == 0 {
+		return v
+	}
+	return scale(v, 1/l)
+}
@@ -25,19 +25,6 @@ func NewVertex(position [3]float32, color [3]float32, uv [2]float32, normal [3]f
 	return Vertex{Position: position, Color: color, UV: uv, Normal: normal}
 }
 
-func setVertexAttributes() {
-	Assert(unsafe.Sizeof(Vertex{}) == 44, "Vertex size must be 44 bytes")
-	gl.EnableVertexAttribArray(0)
-	gl.EnableVertexAttribArray(1)
-	gl.EnableVertexAttribArray(2)
-	gl.EnableVertexAttribArray(3)
-
-	gl.VertexAttribPointerWithOffset(0, 3, gl.FLOAT, false, 44, 0)
-	gl.VertexAttribPointerWithOffset(1, 3, gl.FLOAT, false, 44, 12)
-	gl.VertexAttribPointerWithOffset(2, 2, gl.FLOAT, false, 44, 24)
-	gl.VertexAttribPointerWithOffset(3, 3, gl.FLOAT, false, 44, 32)
-}
-
 type Mesh struct {
 	VAO          uint32
 	VBO          uint32
@@ -45,57 +32,54 @@ type Mesh struct {
 	DrawMode     DrawMode
 	Count        int32
 	DrawElements bool
-}
-
-func NewMesh(vertices []Vertex, indices []uint32, drawMode DrawMode) *Mesh {
-
-	var vao, vbo, ebo uint32
-	gl.GenVertexArrays(1, &vao)
-	gl.BindVertexArray(vao)
 
-	count := int32(len(vertices))
+	Layout VertexLayout
+	Usage  BufferUsage
 
-	gl.GenBuffers(1, &vbo)
-	gl.BindBuffer(gl.ARRAY_BUFFER, vbo)
-	gl.BufferData(gl.ARRAY_BUFFER, len(vertices)*44, gl.Ptr(vertices), gl.STATIC_DRAW)
+	// First is the first vertex drawn, or the value added to every index of indexed meshes.
+	First int32
 
-	if len(indices) > 0 {
-		gl.GenBuffers(1, &ebo)
-		gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, ebo)
-		gl.BufferData(gl.ELEMENT_ARRAY_BUFFER, len(indices)*4, gl.Ptr(indices), gl.STATIC_DRAW)
-		count = int32(len(indices))
-	}
+	vertexCapacity int // in bytes
+	indexCapacity  int
 
-	setVertexAttributes()
+	instanceVBO      uint32
+	instanceCapacity int
 
-	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
-	gl.BindVertexArray(0)
+	// buffers of meshes built from separate attribute streams, VBO is the first
+	streams []uint32
+}
 
-	return &Mesh{
-		VAO:          vao,
-		VBO:          vbo,
-		EBO:          ebo,
-		DrawMode:     drawMode,
-		Count:        count,
-		DrawElements: len(indices) > 0,
-	}
+func NewMesh(vertices []Vertex, indices []uint32, drawMode DrawMode) *Mesh {
+	Assert(unsafe.Sizeof(Vertex{}) == 44, "Vertex size must be 44 bytes")
+	return NewMeshFromVertices(vertices, indices, DefaultVertexLayout, drawMode)
 }
 
 func (m *Mesh) Delete() {
-	gl.DeleteBuffers(1, &m.VAO)
-	gl.DeleteBuffers(1, &m.VBO)
-	if m.Count > 0 {
+	gl.DeleteVertexArrays(1, &m.VAO)
+	if len(m.streams) > 0 {
+		gl.DeleteBuffers(int32(len(m.streams)), &m.streams[0])
+	} else {
+		gl.DeleteBuffers(1, &m.VBO)
+	}
+	if m.EBO != 0 {
 		gl.DeleteBuffers(1, &m.EBO)
 	}
+	if m.instanceVBO != 0 {
+		gl.DeleteBuffers(1, &m.instanceVBO)
+	}
 }
 
 func (m *Mesh) Draw() {
 	gl.BindVertexArray(m.VAO)
+	m.drawCall()
+	gl.BindVertexArray(0)
+}
 
+// drawCall issues the draw, with the mesh's vertex array already bound.
+func (m *Mesh) drawCall() {
 	if m.DrawElements {
-		gl.DrawElements(uint32(m.DrawMode), m.Count, gl.UNSIGNED_INT, nil)
+		gl.DrawElementsBaseVertex(uint32(m.DrawMode), m.Count, gl.UNSIGNED_INT, nil, m.First)
 	} else {
-		gl.DrawArrays(uint32(m.DrawMode), 0, m.Count)
+		gl.DrawArrays(uint32(m.DrawMode), m.First, m.Count)
 	}
-	gl.BindVertexArray(0)
 }
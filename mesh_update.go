@@ -0,0 +1,209 @@
+package noor
+
+import (
+	"time"
+	"unsafe"
+
+	"github.com/go-gl/gl/v4.6-core/gl"
+)
+
+// BufferUsage tells the driver how often the data of a mesh changes.
+type BufferUsage uint32
+
+const (
+	StaticDraw  BufferUsage = gl.STATIC_DRAW  // uploaded once
+	DynamicDraw BufferUsage = gl.DYNAMIC_DRAW // updated now and then
+	StreamDraw  BufferUsage = gl.STREAM_DRAW  // rewritten every frame
+)
+
+// SetVertices replaces the vertices of a mesh created with NewMeshFromVertices.
+// The buffer grows when they do not fit; the draw count of non indexed meshes
+// follows the number of vertices.
+func SetVertices[T any](m *Mesh, vertices []T) {
+	UpdateVertices(m, 0, vertices)
+	if !m.DrawElements {
+		m.Count = int32(len(vertices))
+	}
+}
+
+// UpdateVertices overwrites vertices starting at vertex first, keeping the
+// others. The buffer grows to fit.
+func UpdateVertices[T any](m *Mesh, first int, vertices []T) {
+	Assert(len(m.streams) == 0, "meshes built from streams cannot be updated")
+	stride := int(m.Layout.Stride)
+	Assert(int(unsafe.Sizeof(*new(T))) == stride, "vertex type does not match the mesh layout")
+
+	offset := first * stride
+	size := len(vertices) * stride
+	if offset+size > m.vertexCapacity {
+		m.growVertices(offset + size)
+	}
+	if size == 0 {
+		return
+	}
+
+	gl.BindBuffer(gl.ARRAY_BUFFER, m.VBO)
+	gl.BufferSubData(gl.ARRAY_BUFFER, offset, size, gl.Ptr(vertices))
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+}
+
+// SetIndices replaces the indices of the mesh and its draw count.
+func (m *Mesh) SetIndices(indices []uint32) {
+	m.UpdateIndices(0, indices)
+	m.Count = int32(len(indices))
+	m.DrawElements = len(indices) > 0
+}
+
+// UpdateIndices overwrites indices starting at index first. The buffer grows to fit.
+func (m *Mesh) UpdateIndices(first int, indices []uint32) {
+	offset := first * 4
+	size := len(indices) * 4
+	if offset+size > m.indexCapacity {
+		m.growIndices(offset + size)
+	}
+	if size == 0 {
+		return
+	}
+
+	gl.BindVertexArray(m.VAO)
+	gl.BufferSubData(gl.ELEMENT_ARRAY_BUFFER, offset, size, gl.Ptr(indices))
+	gl.BindVertexArray(0)
+}
+
+// growVertices reallocates the vertex buffer to hold at least size bytes,
+// keeping its content, and points the vertex array at the new buffer.
+func (m *Mesh) growVertices(size int) {
+	capacity := max(size, m.vertexCapacity*2)
+	buffer := m.resizeBuffer(m.VBO, m.vertexCapacity, capacity)
+
+	gl.BindVertexArray(m.VAO)
+	gl.BindBuffer(gl.ARRAY_BUFFER, buffer)
+	m.Layout.apply()
+	gl.BindVertexArray(0)
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+
+	m.VBO = buffer
+	m.vertexCapacity = capacity
+}
+
+func (m *Mesh) growIndices(size int) {
+	capacity := max(size, m.indexCapacity*2)
+	buffer := m.resizeBuffer(m.EBO, m.indexCapacity, capacity)
+
+	// the element buffer binding is part of the vertex array state
+	gl.BindVertexArray(m.VAO)
+	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, buffer)
+	gl.BindVertexArray(0)
+
+	m.EBO = buffer
+	m.indexCapacity = capacity
+}
+
+// resizeBuffer creates a buffer of capacity bytes holding the first used bytes
+// of old, and deletes old.
+func (m *Mesh) resizeBuffer(old uint32, used, capacity int) uint32 {
+	var buffer uint32
+	gl.GenBuffers(1, &buffer)
+	gl.BindBuffer(gl.COPY_WRITE_BUFFER, buffer)
+	gl.BufferData(gl.COPY_WRITE_BUFFER, capacity, nil, uint32(m.Usage))
+
+	if old != 0 {
+		if used > 0 {
+			gl.BindBuffer(gl.COPY_READ_BUFFER, old)
+			gl.CopyBufferSubData(gl.COPY_READ_BUFFER, gl.COPY_WRITE_BUFFER, 0, 0, used)
+			gl.BindBuffer(gl.COPY_READ_BUFFER, 0)
+		}
+		gl.DeleteBuffers(1, &old)
+	}
+
+	gl.BindBuffer(gl.COPY_WRITE_BUFFER, 0)
+	return buffer
+}
+
+// PersistentMesh streams vertices through a persistently mapped buffer, split
+// in regions written in turn so the CPU never waits for the GPU to finish
+// reading the region it writes. Each frame call Begin, fill the returned slice,
+// call End with the number of vertices written and draw the mesh.
+type PersistentMesh[T any] struct {
+	*Mesh
+
+	capacity int // vertices per region
+	region   int
+	mapped   []T
+	fences   []uintptr
+}
+
+// PersistentRegions is the number of frames a PersistentMesh can have in flight.
+const PersistentRegions = 3
+
+func NewPersistentMesh[T any](capacity int, layout VertexLayout, drawMode DrawMode) *PersistentMesh[T] {
+	stride := int(unsafe.Sizeof(*new(T)))
+	if layout.Stride == 0 {
+		layout.Stride = int32(stride)
+	}
+	size := capacity * stride * PersistentRegions
+
+	m := newMesh(nil, drawMode)
+	m.Layout = layout
+	m.Usage = StreamDraw
+
+	const flags = gl.MAP_WRITE_BIT | gl.MAP_PERSISTENT_BIT | gl.MAP_COHERENT_BIT
+	gl.GenBuffers(1, &m.VBO)
+	gl.BindBuffer(gl.ARRAY_BUFFER, m.VBO)
+	gl.BufferStorage(gl.ARRAY_BUFFER, size, nil, flags)
+	ptr := gl.MapBufferRange(gl.ARRAY_BUFFER, 0, size, flags)
+	layout.apply()
+	m.finish(0, nil)
+
+	return &PersistentMesh[T]{
+		Mesh:     m,
+		capacity: capacity,
+		region:   PersistentRegions - 1,
+		mapped:   unsafe.Slice((*T)(ptr), capacity*PersistentRegions),
+		fences:   make([]uintptr, PersistentRegions),
+	}
+}
+
+// Begin moves to the next region, waiting for the GPU to be done with it,
+// and returns it for writing.
+func (p *PersistentMesh[T]) Begin() []T {
+	// fence the draws issued from the previous region
+	if p.fences[p.region] != 0 {
+		gl.DeleteSync(p.fences[p.region])
+	}
+	p.fences[p.region] = gl.FenceSync(gl.SYNC_GPU_COMMANDS_COMPLETE, 0)
+
+	p.region = (p.region + 1) % PersistentRegions
+	if fence := p.fences[p.region]; fence != 0 {
+		for {
+			status := gl.ClientWaitSync(fence, gl.SYNC_FLUSH_COMMANDS_BIT, uint64(time.Second))
+			if status == gl.ALREADY_SIGNALED || status == gl.CONDITION_SATISFIED || status == gl.WAIT_FAILED {
+				break
+			}
+		}
+		gl.DeleteSync(fence)
+		p.fences[p.region] = 0
+	}
+
+	start := p.region * p.capacity
+	return p.mapped[start : start+p.capacity]
+}
+
+// End makes the mesh draw the first count vertices of the current region.
+func (p *PersistentMesh[T]) End(count int) {
+	p.First = int32(p.region * p.capacity)
+	p.Count = int32(min(count, p.capacity))
+}
+
+func (p *PersistentMesh[T]) Delete() {
+	for _, fence := range p.fences {
+		if fence != 0 {
+			gl.DeleteSync(fence)
+		}
+	}
+	gl.BindBuffer(gl.ARRAY_BUFFER, p.VBO)
+	gl.UnmapBuffer(gl.ARRAY_BUFFER)
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+	p.mapped = nil
+	p.Mesh.Delete()
+}
@@ -0,0 +1,299 @@
+// Package noortest compares headless renders against golden PNG images.
+//
+// The renders come from noor.NewHeadless, a hidden GLFW window, so they need
+// a display server; on machines without one run the tests under xvfb-run.
+// Without a display the render tests are skipped.
+//
+// GLFW has to run on the main thread, which only TestMain runs on, so a
+// package using noortest hands its TestMain to Main. Main creates one headless
+// context shared by all tests of the binary and runs every GL call on the main
+// thread:
+//
+//	func TestMain(m *testing.M) {
+//		noortest.Main(m)
+//	}
+//
+//	func TestCube(t *testing.T) {
+//		noortest.RenderGolden(t, "cube", noortest.DefaultOptions(), func(n *noor.Noor) {
+//			n.AddObject(noor.NewObject("cube", noor.NewCubeMesh(1)))
+//		})
+//	}
+//
+// Goldens live in testdata/<name>.png. Run go test -update to write them
+// from the current renders. On a mismatch the render and a diff image, with
+// differing pixels in red, are written to testdata/failed.
+package noortest
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/ahmedsat/noor"
+)
+
+var update = flag.Bool("update", false, "write golden images from the current renders")
+
+type Options struct {
+	Width, Height int
+
+	// Tolerance is how far a channel may differ, 0 to 255, for the pixel to match.
+	Tolerance uint8
+	// MaxDiffRatio is the fraction of pixels allowed to mismatch, 0 to 1.
+	MaxDiffRatio float64
+
+	// Dir holds the goldens, testdata when empty.
+	Dir string
+}
+
+// DefaultOptions renders at 256x256 and accepts small driver differences:
+// channels off by 2 and 0.1% of the pixels.
+func DefaultOptions() Options {
+	return Options{
+		Width:        256,
+		Height:       256,
+		Tolerance:    2,
+		MaxDiffRatio: 0.001,
+		Dir:          "testdata",
+	}
+}
+
+var (
+	// calls carries functions to run on the main thread, set by Main
+	calls      chan func()
+	shared     *noor.Noor
+	contextErr error
+)
+
+// Main runs the tests of m, serving the GL calls of Do on the calling main
+// thread, and exits with their status. Call it from TestMain.
+func Main(m *testing.M) {
+	runtime.LockOSThread()
+
+	width, height := DefaultOptions().Width, DefaultOptions().Height
+	if result := noor.NewHeadless(width, height); result.IsErr() {
+		contextErr = result.Err
+	} else {
+		n := result.Ok
+		shared = &n
+	}
+
+	calls = make(chan func())
+	done := make(chan int)
+	go func() { done <- m.Run() }()
+
+	for {
+		select {
+		case fn := <-calls:
+			fn()
+		case code := <-done:
+			if shared != nil {
+				shared.Close()
+			}
+			os.Exit(code)
+		}
+	}
+}
+
+// Do runs fn with the shared context on the main thread. fn must not call
+// t.Fatal or t.SkipNow, which would stop the main thread instead of the test;
+// it should return what the test checks afterwards. The test is skipped when
+// no OpenGL context could be created, unless the NOORTEST_REQUIRE_GL
+// environment variable is set, then it fails.
+func Do(t testing.TB, fn func(n *noor.Noor)) {
+	t.Helper()
+	if calls == nil {
+		t.Fatal("noortest: no GL thread, call noortest.Main from TestMain")
+	}
+	if shared == nil {
+		if os.Getenv("NOORTEST_REQUIRE_GL") != "" {
+			t.Fatalf("no OpenGL context: %v", contextErr)
+		}
+		t.Skipf("no OpenGL context, noor.NewHeadless needs a display (run under xvfb-run): %v", contextErr)
+	}
+
+	finished := make(chan any)
+	calls <- func() {
+		defer func() { finished <- recover() }()
+		fn(shared)
+	}
+	if p := <-finished; p != nil {
+		t.Fatalf("panic on the GL thread: %v", p)
+	}
+}
+
+// RenderGolden renders the scene built by setup on a fresh scene of the shared
+// context and compares it with the golden called name. setup runs on the main
+// thread, see Do.
+func RenderGolden(t testing.TB, name string, opts Options, setup func(n *noor.Noor)) {
+	t.Helper()
+	opts = opts.withDefaults()
+
+	var img *image.RGBA
+	var err error
+	Do(t, func(n *noor.Noor) {
+		n.Scene = noor.NewScene()
+		n.SetBackground(color.Black)
+		defer func() {
+			if n.Post != nil {
+				n.Post.Delete()
+				n.Post = nil
+			}
+		}()
+
+		if err = n.SetImageSize(opts.Width, opts.Height); err != nil {
+			return
+		}
+		setup(n)
+		img, err = n.RenderImage()
+	})
+	if err != nil {
+		t.Fatalf("rendering %s: %v", name, err)
+	}
+	AssertGolden(t, name, img, opts)
+}
+
+// AssertGolden compares img with the golden called name, or writes it with -update.
+func AssertGolden(t testing.TB, name string, img image.Image, opts Options) {
+	t.Helper()
+	result, err := checkGolden(name, img, opts.withDefaults(), *update)
+	switch {
+	case err != nil:
+		t.Fatal(err)
+	case result.updated:
+		t.Logf("updated golden %s", result.path)
+	case !result.ok():
+		t.Error(result)
+	}
+}
+
+// goldenResult is the outcome of comparing a render with its golden.
+type goldenResult struct {
+	name, path string
+	opts       Options
+	updated    bool
+	mismatched int
+	total      int
+	failedDir  string // where the render and the diff were written, if they were
+}
+
+func (r goldenResult) ratio() float64 {
+	return float64(r.mismatched) / float64(max(r.total, 1))
+}
+
+func (r goldenResult) ok() bool {
+	return r.updated || r.ratio() <= r.opts.MaxDiffRatio
+}
+
+func (r goldenResult) String() string {
+	return fmt.Sprintf("%s: %d of %d pixels (%.3f%%) differ from %s by more than %d, allowed %.3f%%; see %s",
+		r.name, r.mismatched, r.total, r.ratio()*100, r.path, r.opts.Tolerance, r.opts.MaxDiffRatio*100, r.failedDir)
+}
+
+func checkGolden(name string, img image.Image, opts Options, update bool) (goldenResult, error) {
+	result := goldenResult{name: name, path: filepath.Join(opts.Dir, name+".png"), opts: opts}
+
+	if update {
+		if err := os.MkdirAll(filepath.Dir(result.path), 0o755); err != nil {
+			return result, fmt.Errorf("creating golden directory: %w", err)
+		}
+		if err := noor.SavePNG(result.path, img); err != nil {
+			return result, fmt.Errorf("writing golden: %w", err)
+		}
+		result.updated = true
+		return result, nil
+	}
+
+	want, err := loadPNG(result.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return result, fmt.Errorf("golden %s does not exist, run go test -update to create it", result.path)
+	}
+	if err != nil {
+		return result, fmt.Errorf("reading golden: %w", err)
+	}
+
+	if want.Bounds().Size() != img.Bounds().Size() {
+		return result, fmt.Errorf("%s: render is %v, golden is %v", name, img.Bounds().Size(), want.Bounds().Size())
+	}
+
+	diff, mismatched := Compare(img, want, opts.Tolerance)
+	result.mismatched = mismatched
+	result.total = img.Bounds().Dx() * img.Bounds().Dy()
+	if result.ok() {
+		return result, nil
+	}
+
+	failed := filepath.Join(opts.Dir, "failed")
+	if err := os.MkdirAll(failed, 0o755); err == nil &&
+		noor.SavePNG(filepath.Join(failed, name+".png"), img) == nil &&
+		noor.SavePNG(filepath.Join(failed, name+".diff.png"), diff) == nil {
+		result.failedDir = failed
+	}
+	return result, nil
+}
+
+// Compare counts the pixels of got differing from want by more than tolerance
+// in any channel. The diff image shows them in red over a faded copy of want.
+// Both images must have the same size.
+func Compare(got, want image.Image, tolerance uint8) (diff *image.RGBA, mismatched int) {
+	gb, wb := got.Bounds(), want.Bounds()
+	diff = image.NewRGBA(image.Rect(0, 0, gb.Dx(), gb.Dy()))
+
+	for y := range gb.Dy() {
+		for x := range gb.Dx() {
+			g := color.NRGBAModel.Convert(got.At(gb.Min.X+x, gb.Min.Y+y)).(color.NRGBA)
+			w := color.NRGBAModel.Convert(want.At(wb.Min.X+x, wb.Min.Y+y)).(color.NRGBA)
+
+			if channelDiff(g.R, w.R) > tolerance || channelDiff(g.G, w.G) > tolerance ||
+				channelDiff(g.B, w.B) > tolerance || channelDiff(g.A, w.A) > tolerance {
+				mismatched++
+				diff.SetRGBA(x, y, color.RGBA{R: 0xff, A: 0xff})
+				continue
+			}
+
+			grey := uint8((uint16(w.R) + uint16(w.G) + uint16(w.B)) / 3 / 4)
+			diff.SetRGBA(x, y, color.RGBA{R: grey, G: grey, B: grey, A: 0xff})
+		}
+	}
+	return diff, mismatched
+}
+
+func channelDiff(a, b uint8) uint8 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+func loadPNG(path string) (image.Image, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	img, err := png.Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	return img, nil
+}
+
+func (o Options) withDefaults() Options {
+	defaults := DefaultOptions()
+	if o.Width == 0 || o.Height == 0 {
+		o.Width, o.Height = defaults.Width, defaults.Height
+	}
+	if o.Dir == "" {
+		o.Dir = defaults.Dir
+	}
+	return o
+}
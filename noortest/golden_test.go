@@ -0,0 +1,158 @@
+package noortest
+
+import (
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ahmedsat/noor"
+)
+
+func filled(width, height int, c color.RGBA) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = c.R, c.G, c.B, c.A
+	}
+	return img
+}
+
+func TestCompare(t *testing.T) {
+	grey := color.RGBA{R: 100, G: 100, B: 100, A: 255}
+	want := filled(4, 4, grey)
+
+	got := filled(4, 4, grey)
+	got.SetRGBA(1, 2, color.RGBA{R: 102, G: 98, B: 100, A: 255})
+	got.SetRGBA(3, 0, color.RGBA{R: 100, G: 100, B: 103, A: 255})
+	got.SetRGBA(0, 3, color.RGBA{R: 100, G: 100, B: 100, A: 250})
+
+	diff, mismatched := Compare(got, want, 2)
+	if mismatched != 2 {
+		t.Errorf("got %d mismatched pixels, want 2", mismatched)
+	}
+
+	red := color.RGBA{R: 0xff, A: 0xff}
+	for _, p := range []image.Point{{3, 0}, {0, 3}} {
+		if c := diff.RGBAAt(p.X, p.Y); c != red {
+			t.Errorf("diff at %v is %v, want red", p, c)
+		}
+	}
+	// matching pixels are a faded grey copy of want
+	if c := diff.RGBAAt(1, 2); c.R != c.G || c.G != c.B || c.R != 100/4 {
+		t.Errorf("diff at (1, 2) is %v, want a faded grey", c)
+	}
+
+	if _, mismatched := Compare(got, want, 5); mismatched != 0 {
+		t.Errorf("got %d mismatched pixels with tolerance 5, want 0", mismatched)
+	}
+}
+
+func TestCompareOffsetBounds(t *testing.T) {
+	want := filled(2, 2, color.RGBA{R: 10, A: 255})
+	got := filled(4, 4, color.RGBA{R: 10, A: 255}).SubImage(image.Rect(2, 2, 4, 4))
+
+	diff, mismatched := Compare(got, want, 0)
+	if mismatched != 0 {
+		t.Errorf("got %d mismatched pixels for a sub image, want 0", mismatched)
+	}
+	if diff.Bounds() != image.Rect(0, 0, 2, 2) {
+		t.Errorf("diff bounds are %v", diff.Bounds())
+	}
+}
+
+func TestCheckGolden(t *testing.T) {
+	dir := t.TempDir()
+	want := filled(10, 10, color.RGBA{R: 50, G: 150, B: 250, A: 255})
+	if err := noor.SavePNG(filepath.Join(dir, "sky.png"), want); err != nil {
+		t.Fatal(err)
+	}
+
+	// 2 of 100 pixels differ beyond the tolerance
+	got := filled(10, 10, color.RGBA{R: 51, G: 149, B: 250, A: 255})
+	got.SetRGBA(0, 0, color.RGBA{A: 255})
+	got.SetRGBA(9, 9, color.RGBA{A: 255})
+
+	tests := []struct {
+		name      string
+		tolerance uint8
+		ratio     float64
+		ok        bool
+	}{
+		{"within ratio", 1, 0.02, true},
+		{"over ratio", 1, 0.01, false},
+		{"under tolerance", 0, 0.5, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			opts := Options{Width: 10, Height: 10, Tolerance: test.tolerance, MaxDiffRatio: test.ratio, Dir: dir}
+			result, err := checkGolden("sky", got, opts, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.ok() != test.ok {
+				t.Errorf("ok is %v with %d mismatched pixels, want %v", result.ok(), result.mismatched, test.ok)
+			}
+			if !test.ok && result.failedDir == "" {
+				t.Error("the render and diff of a failure were not written")
+			}
+		})
+	}
+
+	for _, name := range []string{"sky.png", "sky.diff.png"} {
+		if _, err := os.Stat(filepath.Join(dir, "failed", name)); err != nil {
+			t.Errorf("failed/%s was not written: %v", name, err)
+		}
+	}
+}
+
+func TestCheckGoldenErrors(t *testing.T) {
+	dir := t.TempDir()
+	opts := Options{Dir: dir}.withDefaults()
+	img := filled(4, 4, color.RGBA{A: 255})
+
+	_, err := checkGolden("missing", img, opts, false)
+	if err == nil || !strings.Contains(err.Error(), "-update") {
+		t.Errorf("a missing golden gave %v, want a hint to run -update", err)
+	}
+
+	if err := noor.SavePNG(filepath.Join(dir, "small.png"), filled(2, 2, color.RGBA{A: 255})); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := checkGolden("small", img, opts, false); err == nil {
+		t.Error("a golden of another size was accepted")
+	}
+}
+
+func TestCheckGoldenUpdate(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "nested")
+	opts := Options{Dir: dir}.withDefaults()
+	img := filled(3, 5, color.RGBA{R: 1, G: 2, B: 3, A: 255})
+
+	result, err := checkGolden("written", img, opts, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.updated || !result.ok() {
+		t.Errorf("update gave %+v", result)
+	}
+
+	result, err = checkGolden("written", img, opts, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.ok() || result.mismatched != 0 {
+		t.Errorf("the updated golden does not match its render: %v", result)
+	}
+}
+
+func TestOptionsDefaults(t *testing.T) {
+	opts := Options{Tolerance: 7}.withDefaults()
+	if opts.Width != 256 || opts.Height != 256 || opts.Dir != "testdata" {
+		t.Errorf("got %+v, want the size and directory of DefaultOptions", opts)
+	}
+	if opts.Tolerance != 7 || opts.MaxDiffRatio != 0 {
+		t.Errorf("set fields were changed: %+v", opts)
+	}
+}
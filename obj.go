@@ -0,0 +1,499 @@
+package noor
+
+import (
+	"bufio"
+	"fmt"
+	"image/color"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ahmedsat/madar"
+)
+
+// OBJMaterial holds the properties of a material defined in a .mtl file.
+// Texture map paths are resolved relative to the .mtl file.
+type OBJMaterial struct {
+	Name      string
+	Ambient   [3]float32
+	Diffuse   [3]float32
+	Specular  [3]float32
+	Emissive  [3]float32
+	Shininess float32
+	Opacity   float32
+
+	DiffuseMap  string
+	SpecularMap string
+	NormalMap   string
+	EmissiveMap string
+}
+
+// OBJGroup is a run of faces sharing a group name and a material,
+// already deduplicated into a vertex and index buffer.
+type OBJGroup struct {
+	Name     string
+	Material string
+	Vertices []Vertex
+	Indices  []uint32
+}
+
+type OBJModel struct {
+	Groups    []*OBJGroup
+	Materials map[string]*OBJMaterial
+}
+
+// LoadOBJ parses a Wavefront .obj file and its .mtl libraries and returns one
+// object per group and material. Each .mtl material becomes a Material shared by
+// its objects, with texture maps loaded through NewTextureFromFile.
+func LoadOBJ(path string) ([]*Object, error) {
+	model, err := ParseOBJFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	params := DefaultTextureParameters()
+	params.FlipImage = true // OBJ texture coordinates start at the bottom left
+
+	textures := map[string]*Texture{}
+	loadTexture := func(path string) (*Texture, error) {
+		if tex, ok := textures[path]; ok {
+			return tex, nil
+		}
+		tex, err := NewTextureFromFile(path, params)
+		if err != nil {
+			return nil, err
+		}
+		textures[path] = tex
+		return tex, nil
+	}
+
+	materials := map[string]*Material{}
+	for name, m := range model.Materials {
+		mat, err := m.toMaterial(loadTexture)
+		if err != nil {
+			// free what was uploaded for the materials before this one
+			for _, mat := range materials {
+				mat.Delete()
+			}
+			for _, tex := range textures {
+				tex.Delete()
+			}
+			return nil, fmt.Errorf("failed to create material %s: %w", name, err)
+		}
+		materials[name] = mat
+	}
+
+	objects := make([]*Object, 0, len(model.Groups))
+	for _, group := range model.Groups {
+		obj := NewObject(group.Name, NewMesh(group.Vertices, group.Indices, DrawTriangles))
+		obj.Material = materials[group.Material]
+		objects = append(objects, obj)
+	}
+
+	return objects, nil
+}
+
+func (m *OBJMaterial) toMaterial(loadTexture func(string) (*Texture, error)) (*Material, error) {
+	mat, err := NewDefaultMaterial(m.Name).Unwrap()
+	if err != nil {
+		return nil, err
+	}
+
+	mat.SetColor("uDiffuseColor", vec3ToColor(m.Diffuse, m.Opacity))
+	mat.SetColor("uSpecularColor", vec3ToColor(m.Specular, 1))
+	mat.SetColor("uEmissiveColor", vec3ToColor(m.Emissive, 1))
+	mat.SetFloat("uShininess", m.Shininess)
+	if m.Opacity < 1 {
+		mat.State.Blend = BlendAlpha
+	}
+
+	maps := map[TextureSlot]string{
+		SlotDiffuse:  m.DiffuseMap,
+		SlotSpecular: m.SpecularMap,
+		SlotNormal:   m.NormalMap,
+		SlotEmissive: m.EmissiveMap,
+	}
+	for slot, path := range maps {
+		if path == "" {
+			continue
+		}
+		tex, err := loadTexture(path)
+		if err != nil {
+			mat.Delete()
+			return nil, err
+		}
+		mat.SetTexture(slot, tex)
+	}
+
+	// a map multiplies the constant color, an unset Ke would hide the emissive map
+	if m.EmissiveMap != "" && m.Emissive == [3]float32{} {
+		mat.SetColor("uEmissiveColor", color.White)
+	}
+
+	return mat, nil
+}
+
+// ParseOBJFile parses a Wavefront .obj file without touching the GPU.
+func ParseOBJFile(path string) (*OBJModel, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open obj file %s: %w", path, err)
+	}
+	defer file.Close()
+
+	model, err := ParseOBJ(file, filepath.Dir(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse obj file %s: %w", path, err)
+	}
+	return model, nil
+}
+
+type objIndex struct {
+	v, vt, vn int
+}
+
+type objBuilder struct {
+	positions [][3]float32
+	uvs       [][2]float32
+	normals   [][3]float32
+
+	model   *OBJModel
+	current *OBJGroup
+	lookup  map[objIndex]uint32
+
+	// per vertex flags used to generate normals for faces that have none
+	missingNormal []bool
+
+	groupName string
+	material  string
+}
+
+// ParseOBJ parses Wavefront .obj data. Material libraries referenced with
+// mtllib are loaded from dir.
+func ParseOBJ(r io.Reader, dir string) (*OBJModel, error) {
+	b := &objBuilder{
+		model:     &OBJModel{Materials: map[string]*OBJMaterial{}},
+		groupName: "default",
+	}
+
+	scanner := bufio.NewScanner(r)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		fields := strings.Fields(stripComment(scanner.Text()))
+		if len(fields) == 0 {
+			continue
+		}
+
+		var err error
+		switch fields[0] {
+		case "v":
+			var p [3]float32
+			err = parseFloats(fields[1:], p[:], 3)
+			b.positions = append(b.positions, p)
+		case "vt":
+			var uv [2]float32
+			err = parseFloats(fields[1:], uv[:], 1)
+			b.uvs = append(b.uvs, uv)
+		case "vn":
+			var n [3]float32
+			err = parseFloats(fields[1:], n[:], 3)
+			b.normals = append(b.normals, n)
+		case "f":
+			err = b.face(fields[1:])
+		case "g", "o":
+			b.finishGroup()
+			b.groupName = strings.Join(fields[1:], " ")
+		case "usemtl":
+			b.finishGroup()
+			b.material = strings.Join(fields[1:], " ")
+		case "mtllib":
+			for _, name := range fields[1:] {
+				if err = parseMTLFile(filepath.Join(dir, name), b.model.Materials); err != nil {
+					break
+				}
+			}
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	b.finishGroup()
+	return b.model, nil
+}
+
+func (b *objBuilder) face(fields []string) error {
+	if len(fields) < 3 {
+		return fmt.Errorf("face needs at least 3 vertices, got %d", len(fields))
+	}
+
+	if b.current == nil {
+		b.current = &OBJGroup{Name: b.groupName, Material: b.material}
+		b.model.Groups = append(b.model.Groups, b.current)
+		b.lookup = map[objIndex]uint32{}
+		b.missingNormal = b.missingNormal[:0]
+	}
+
+	corners := make([]uint32, len(fields))
+	for i, field := range fields {
+		idx, err := b.parseIndex(field)
+		if err != nil {
+			return err
+		}
+		corners[i] = b.vertex(idx)
+	}
+
+	// triangulate convex polygons as a fan around the first corner
+	for i := 1; i+1 < len(corners); i++ {
+		b.current.Indices = append(b.current.Indices, corners[0], corners[i], corners[i+1])
+	}
+	return nil
+}
+
+func (b *objBuilder) parseIndex(field string) (objIndex, error) {
+	idx := objIndex{v: -1, vt: -1, vn: -1}
+	parts := strings.Split(field, "/")
+
+	resolve := func(s string, count int) (int, error) {
+		if s == "" {
+			return -1, nil
+		}
+		i, err := strconv.Atoi(s)
+		if err != nil {
+			return -1, fmt.Errorf("invalid face index %q: %w", s, err)
+		}
+		if i < 0 {
+			i += count // negative indices are relative to the end of the list
+		} else {
+			i--
+		}
+		if i < 0 || i >= count {
+			return -1, fmt.Errorf("face index %s out of range", s)
+		}
+		return i, nil
+	}
+
+	var err error
+	if idx.v, err = resolve(parts[0], len(b.positions)); err != nil {
+		return idx, err
+	}
+	if idx.v < 0 {
+		return idx, fmt.Errorf("face vertex %q has no position", field)
+	}
+	if len(parts) > 1 {
+		if idx.vt, err = resolve(parts[1], len(b.uvs)); err != nil {
+			return idx, err
+		}
+	}
+	if len(parts) > 2 {
+		if idx.vn, err = resolve(parts[2], len(b.normals)); err != nil {
+			return idx, err
+		}
+	}
+	return idx, nil
+}
+
+// vertex returns the index of the deduplicated vertex for idx, adding it if needed.
+func (b *objBuilder) vertex(idx objIndex) uint32 {
+	if i, ok := b.lookup[idx]; ok {
+		return i
+	}
+
+	v := Vertex{Position: b.positions[idx.v], Color: [3]float32{1, 1, 1}}
+	if idx.vt >= 0 {
+		v.UV = b.uvs[idx.vt]
+	}
+	if idx.vn >= 0 {
+		v.Normal = b.normals[idx.vn]
+	}
+
+	i := uint32(len(b.current.Vertices))
+	b.current.Vertices = append(b.current.Vertices, v)
+	b.missingNormal = append(b.missingNormal, idx.vn < 0)
+	b.lookup[idx] = i
+	return i
+}
+
+// finishGroup generates smooth normals for vertices of the current group that
+// did not specify one.
+func (b *objBuilder) finishGroup() {
+	g := b.current
+	if g == nil {
+		return
+	}
+
+	missing := false
+	for _, m := range b.missingNormal {
+		missing = missing || m
+	}
+
+	if missing {
+		for i := 0; i+2 < len(g.Indices); i += 3 {
+			i0, i1, i2 := g.Indices[i], g.Indices[i+1], g.Indices[i+2]
+			p0, p1, p2 := toVector3(g.Vertices[i0].Position), toVector3(g.Vertices[i1].Position), toVector3(g.Vertices[i2].Position)
+			n := cross(sub(p1, p0), sub(p2, p0))
+			for _, vi := range []uint32{i0, i1, i2} {
+				if b.missingNormal[vi] {
+					g.Vertices[vi].Normal = fromVector3(add(toVector3(g.Vertices[vi].Normal), n))
+				}
+			}
+		}
+		for i := range g.Vertices {
+			if b.missingNormal[i] {
+				g.Vertices[i].Normal = fromVector3(normalize(toVector3(g.Vertices[i].Normal)))
+			}
+		}
+	}
+
+	b.current = nil
+}
+
+func parseMTLFile(path string, materials map[string]*OBJMaterial) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open mtl file %s: %w", path, err)
+	}
+	defer file.Close()
+
+	if err := ParseMTL(file, filepath.Dir(path), materials); err != nil {
+		return fmt.Errorf("failed to parse mtl file %s: %w", path, err)
+	}
+	return nil
+}
+
+// ParseMTL parses a Wavefront .mtl library into materials, keyed by name.
+// Texture map paths are resolved against dir.
+func ParseMTL(r io.Reader, dir string, materials map[string]*OBJMaterial) error {
+	var mat *OBJMaterial
+
+	scanner := bufio.NewScanner(r)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		fields := strings.Fields(stripComment(scanner.Text()))
+		if len(fields) == 0 {
+			continue
+		}
+
+		if fields[0] == "newmtl" {
+			mat = &OBJMaterial{
+				Name:      strings.Join(fields[1:], " "),
+				Diffuse:   [3]float32{1, 1, 1},
+				Opacity:   1,
+				Shininess: 32,
+			}
+			materials[mat.Name] = mat
+			continue
+		}
+
+		if mat == nil {
+			return fmt.Errorf("line %d: %s before newmtl", lineNo, fields[0])
+		}
+
+		var err error
+		switch fields[0] {
+		case "Ka":
+			err = parseFloats(fields[1:], mat.Ambient[:], 3)
+		case "Kd":
+			err = parseFloats(fields[1:], mat.Diffuse[:], 3)
+		case "Ks":
+			err = parseFloats(fields[1:], mat.Specular[:], 3)
+		case "Ke":
+			err = parseFloats(fields[1:], mat.Emissive[:], 3)
+		case "Ns":
+			mat.Shininess, err = parseScalar(fields)
+		case "d":
+			mat.Opacity, err = parseScalar(fields)
+		case "Tr":
+			var tr float32
+			tr, err = parseScalar(fields)
+			mat.Opacity = 1 - tr
+		case "map_Kd":
+			mat.DiffuseMap = mapPath(dir, fields)
+		case "map_Ks":
+			mat.SpecularMap = mapPath(dir, fields)
+		case "map_Bump", "map_bump", "bump", "norm":
+			mat.NormalMap = mapPath(dir, fields)
+		case "map_Ke":
+			mat.EmissiveMap = mapPath(dir, fields)
+		}
+
+		if err != nil {
+			return fmt.Errorf("line %d: %w", lineNo, err)
+		}
+	}
+
+	return scanner.Err()
+}
+
+// mapPath returns the file name of a map_* statement, skipping any options
+// such as "-bm 1.0" that precede it.
+func mapPath(dir string, fields []string) string {
+	if len(fields) < 2 {
+		return ""
+	}
+	name := fields[len(fields)-1]
+	if filepath.IsAbs(name) {
+		return name
+	}
+	return filepath.Join(dir, filepath.FromSlash(strings.ReplaceAll(name, "\\", "/")))
+}
+
+func stripComment(line string) string {
+	if i := strings.IndexByte(line, '#'); i >= 0 {
+		return line[:i]
+	}
+	return line
+}
+
+// parseFloats parses fields into out, requiring at least required values.
+func parseFloats(fields []string, out []float32, required int) error {
+	if len(fields) < required {
+		return fmt.Errorf("expected at least %d values, got %d", required, len(fields))
+	}
+	for i := 0; i < len(out) && i < len(fields); i++ {
+		f, err := parseFloat(fields[i])
+		if err != nil {
+			return err
+		}
+		out[i] = f
+	}
+	return nil
+}
+
+// parseScalar parses the last value of a statement such as "d -halo 0.5".
+func parseScalar(fields []string) (float32, error) {
+	if len(fields) < 2 {
+		return 0, fmt.Errorf("%s needs a value", fields[0])
+	}
+	return parseFloat(fields[len(fields)-1])
+}
+
+func parseFloat(s string) (float32, error) {
+	f, err := strconv.ParseFloat(s, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %q: %w", s, err)
+	}
+	return float32(f), nil
+}
+
+func vec3ToColor(c [3]float32, alpha float32) color.Color {
+	return color.NRGBA64{
+		R: uint16(clamp(c[0], 0, 1) * 0xffff),
+		G: uint16(clamp(c[1], 0, 1) * 0xffff),
+		B: uint16(clamp(c[2], 0, 1) * 0xffff),
+		A: uint16(clamp(alpha, 0, 1) * 0xffff),
+	}
+}
+
+func toVector3(v [3]float32) madar.Vector3 {
+	return madar.Vector3{X: v[0], Y: v[1], Z: v[2]}
+}
+
+func fromVector3(v madar.Vector3) [3]float32 {
+	return [3]float32{v.X, v.Y, v.Z}
+}
@@ -0,0 +1,166 @@
+package noor
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseOBJ(t *testing.T) {
+	const source = `
+# a unit quad split into two groups
+v 0 0 0
+v 1 0 0
+v 1 1 0
+v 0 1 0
+vt 0 0
+vt 1 0
+vt 1 1
+vt 0 1
+vn 0 0 1
+
+g front
+usemtl red
+f 1/1/1 2/2/1 3/3/1 4/4/1
+
+g back
+usemtl blue
+f -1/-1/-1 -2/-2/-1 -3/-3/-1
+`
+	model, err := ParseOBJ(strings.NewReader(source), ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(model.Groups) != 2 {
+		t.Fatalf("got %d groups, want 2", len(model.Groups))
+	}
+
+	front := model.Groups[0]
+	if front.Name != "front" || front.Material != "red" {
+		t.Errorf("front group is %q with %q, want front with red", front.Name, front.Material)
+	}
+	if len(front.Vertices) != 4 {
+		t.Errorf("front has %d vertices, want 4", len(front.Vertices))
+	}
+	// the quad is triangulated as a fan around its first corner
+	wantIndices := []uint32{0, 1, 2, 0, 2, 3}
+	if !slices.Equal(front.Indices, wantIndices) {
+		t.Errorf("front indices are %v, want %v", front.Indices, wantIndices)
+	}
+	if v := front.Vertices[2]; v.Position != [3]float32{1, 1, 0} || v.UV != [2]float32{1, 1} || v.Normal != [3]float32{0, 0, 1} {
+		t.Errorf("front vertex 2 is %+v", v)
+	}
+
+	// negative indices count from the end of the lists
+	back := model.Groups[1]
+	if back.Name != "back" || back.Material != "blue" {
+		t.Errorf("back group is %q with %q, want back with blue", back.Name, back.Material)
+	}
+	if len(back.Vertices) != 3 || back.Vertices[0].Position != [3]float32{0, 1, 0} || back.Vertices[0].UV != [2]float32{0, 1} {
+		t.Errorf("back vertices are %+v", back.Vertices)
+	}
+}
+
+func TestParseOBJSharesVertices(t *testing.T) {
+	const source = `
+v 0 0 0
+v 1 0 0
+v 1 1 0
+v 0 1 0
+f 1 2 3
+f 1 3 4
+`
+	model, err := ParseOBJ(strings.NewReader(source), ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(model.Groups) != 1 {
+		t.Fatalf("got %d groups, want 1", len(model.Groups))
+	}
+
+	g := model.Groups[0]
+	if g.Name != "default" {
+		t.Errorf("group is called %q, want default", g.Name)
+	}
+	if len(g.Vertices) != 4 || len(g.Indices) != 6 {
+		t.Fatalf("got %d vertices and %d indices, want 4 and 6", len(g.Vertices), len(g.Indices))
+	}
+
+	// faces without normals get smooth ones generated from their winding
+	for i, v := range g.Vertices {
+		if v.Normal != [3]float32{0, 0, 1} {
+			t.Errorf("vertex %d has normal %v, want +z", i, v.Normal)
+		}
+	}
+}
+
+func TestParseOBJErrors(t *testing.T) {
+	tests := []struct {
+		name, source, want string
+	}{
+		{"index out of range", "v 0 0 0\nv 1 0 0\nv 1 1 0\nf 1 2 4\n", "line 4: face index 4 out of range"},
+		{"too few corners", "v 0 0 0\nv 1 0 0\nf 1 2\n", "line 3: face needs at least 3 vertices"},
+		{"bad number", "v 0 zero 0\n", `line 1: invalid number "zero"`},
+		{"missing position", "v 0 0 0\nvt 0 0\nf /1 /1 /1\n", "has no position"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ParseOBJ(strings.NewReader(test.source), ".")
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("got error %v, want %q", err, test.want)
+			}
+		})
+	}
+}
+
+func TestParseMTL(t *testing.T) {
+	const source = `
+newmtl brick
+Kd 0.8 0.2 0.1
+Ks 0.5 0.5 0.5
+Ns 64
+d 0.75
+map_Kd -bm 1.0 textures\brick.png
+map_Bump brick_normal.png
+
+newmtl glass
+Tr 0.9
+`
+	materials := map[string]*OBJMaterial{}
+	if err := ParseMTL(strings.NewReader(source), "models", materials); err != nil {
+		t.Fatal(err)
+	}
+
+	brick := materials["brick"]
+	if brick == nil {
+		t.Fatal("material brick is missing")
+	}
+	if brick.Diffuse != [3]float32{0.8, 0.2, 0.1} || brick.Specular != [3]float32{0.5, 0.5, 0.5} {
+		t.Errorf("brick colors are %v and %v", brick.Diffuse, brick.Specular)
+	}
+	if brick.Shininess != 64 || brick.Opacity != 0.75 {
+		t.Errorf("brick shininess and opacity are %v and %v, want 64 and 0.75", brick.Shininess, brick.Opacity)
+	}
+	if want := filepath.Join("models", "textures", "brick.png"); brick.DiffuseMap != want {
+		t.Errorf("brick diffuse map is %q, want %q", brick.DiffuseMap, want)
+	}
+	if want := filepath.Join("models", "brick_normal.png"); brick.NormalMap != want {
+		t.Errorf("brick normal map is %q, want %q", brick.NormalMap, want)
+	}
+
+	glass := materials["glass"]
+	if glass == nil {
+		t.Fatal("material glass is missing")
+	}
+	if diff := glass.Opacity - 0.1; diff > 1e-6 || diff < -1e-6 {
+		t.Errorf("glass opacity is %v, want 0.1", glass.Opacity)
+	}
+	if glass.Diffuse != [3]float32{1, 1, 1} || glass.Shininess != 32 {
+		t.Errorf("glass defaults are %v and %v", glass.Diffuse, glass.Shininess)
+	}
+
+	if err := ParseMTL(strings.NewReader("Kd 1 1 1\n"), ".", materials); err == nil {
+		t.Error("a statement before newmtl was accepted")
+	}
+}
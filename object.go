@@ -1,25 +1,49 @@
 package noor
 
-import "github.com/ahmedsat/madar"
+import (
+	"strings"
+	"unsafe"
+
+	"github.com/ahmedsat/madar"
+)
 
 type Object struct {
 	Name string
 	Mesh *Mesh
 
+	Parent   *Object
+	Children []*Object
+
+	// scene holds the object as a root, see Scene.AddObject
+	scene *Scene
+
 	Position madar.Vector3
 	Rotation madar.Vector3
 	Scale    madar.Vector3
 
+	// Transform, when set, is used as the local matrix instead of
+	// Position, Rotation and Scale. Importers use it for baked node transforms.
+	Transform *[16]float32
+
 	Shader
 	Textures []*Texture
+
+	// Material, when set, replaces Shader for drawing. Textures are still bound,
+	// on the texture units after the material's own.
+	Material *Material
+
+	// State, when set, overrides the render state of the material.
+	State *RenderState
+
+	// Instances, when set, draws the mesh once per instance with a single draw
+	// call. Instance matrices are relative to the object.
+	Instances []InstanceData
 }
 
 func NewObject(name string, mesh *Mesh) *Object {
 
-	defaultShader := CreateShaderProgram(
-		DefaultVertexShader,
-		DefaultFragmentShader,
-	).UnwrapOrPanic()
+	defaultShader := sharedDefaultShader().UnwrapOrPanic()
+	defaultShader.Retain()
 
 	return &Object{
 		Name:     name,
@@ -28,29 +52,223 @@ func NewObject(name string, mesh *Mesh) *Object {
 		Rotation: madar.Vector3{X: 0, Y: 0, Z: 0},
 		Scale:    madar.Vector3{X: 1, Y: 1, Z: 1},
 
+		Children: make([]*Object, 0),
+
 		Shader:   defaultShader,
 		Textures: make([]*Texture, 0),
 	}
 }
 
+// Render draws the object and then its children.
+// Objects without a mesh act as groups and only render their children.
 func (o *Object) Render(camera Camera) {
-	o.Shader.Activate()
+	if o.Mesh != nil {
+		o.draw(camera)
+	}
 
-	for i, tex := range o.Textures {
-		tex.Activate(o.Shader, uint32(i), tex.Name)
+	for _, child := range o.Children {
+		child.Render(camera)
 	}
+}
 
-	o.Shader.SetUniformMatrixFloat32("uView", camera.View())
-	o.Shader.SetUniformMatrixFloat32("uProjection", camera.Projection())
-	o.Shader.SetUniformMatrixFloat32("uModel", o.ModelMatrix())
+func (o *Object) draw(camera Camera) {
+	sh := o.bind(camera)
+	sh.SetUniformMatrixFloat32("uModel", o.ModelMatrix())
+
+	if len(o.Instances) > 0 {
+		SetInstances(o.Mesh, InstanceLayout, o.Instances)
+		setInstanced(sh, true)
+		o.Mesh.DrawInstanced(int32(len(o.Instances)))
+		return
+	}
+
+	setInstanced(sh, false)
 	o.Mesh.Draw()
 }
 
+// bind activates the object's shader or material, binds its textures and sets
+// the camera matrices. It returns the shader in use.
+func (o *Object) bind(camera Camera) Shader {
+	sh := o.ActiveShader()
+
+	unit := uint32(0)
+	if o.Material != nil {
+		unit = o.Material.Apply()
+	} else {
+		sh.Activate()
+		unit = resetMaterialUniforms(sh)
+	}
+	o.RenderState().apply()
+
+	bindTextures(sh, o.Textures, unit)
+
+	sh.SetUniformMatrixFloat32("uView", camera.View())
+	sh.SetUniformMatrixFloat32("uProjection", camera.Projection())
+	return sh
+}
+
+// bindTextures binds textures to consecutive units starting at unit, each to
+// the sampler named after it, and returns the next free unit. The first texture
+// matching no sampler feeds uTexture when the shader has it, so textures named
+// after their file keep working. Other textures matching no sampler are skipped.
+func bindTextures(sh Shader, textures []*Texture, unit uint32) uint32 {
+	fallback := sh.HasUniform("uTexture")
+	for _, tex := range textures {
+		name := tex.Name
+		if !sh.hasSampler(name) {
+			if !fallback {
+				sh.warnUnboundTexture(name)
+				continue
+			}
+			name = "uTexture"
+			fallback = false
+		}
+		tex.Activate(sh, unit, name)
+		unit++
+	}
+	return unit
+}
+
+// ActiveShader returns the shader the object is drawn with: the material's
+// shader if it has a material, its own Shader otherwise.
+func (o *Object) ActiveShader() Shader {
+	if o.Material != nil {
+		return o.Material.Shader
+	}
+	return o.Shader
+}
+
+// RenderState returns the state the object is drawn with: its own State, its
+// material's, or the default state.
+func (o *Object) RenderState() RenderState {
+	switch {
+	case o.State != nil:
+		return *o.State
+	case o.Material != nil:
+		return o.Material.State
+	}
+	return DefaultRenderState()
+}
+
+func (o *Object) SetMaterial(mat *Material) {
+	o.Material = mat
+}
+
+// ModelMatrix returns the world matrix of the object, ready to be uploaded as uModel.
 func (o *Object) ModelMatrix() *float32 {
+	mat := o.WorldMatrix()
+	return &mat[0]
+}
+
+// LocalMatrix returns Transform when set, otherwise it composes the object's
+// own Position, Rotation and Scale.
+func (o *Object) LocalMatrix() [16]float32 {
+	if o.Transform != nil {
+		return *o.Transform
+	}
+
 	var mat madar.Matrix = madar.TranslationMatrix(o.Position.X, o.Position.Y, o.Position.Z)
 	mat = mat.Multiply(madar.RotationMatrix(o.Rotation.X, o.Rotation.Y, o.Rotation.Z))
 	mat = mat.Multiply(madar.ScalingMatrix(o.Scale.X, o.Scale.Y, o.Scale.Z))
-	return mat.Ptr()
+	return *(*[16]float32)(unsafe.Pointer(mat.Ptr()))
+}
+
+// WorldMatrix returns parent world × local, walking up to the root of the hierarchy.
+func (o *Object) WorldMatrix() [16]float32 {
+	mat := o.LocalMatrix()
+	if o.Parent != nil {
+		mat = multiply(o.Parent.WorldMatrix(), mat)
+	}
+	return mat
+}
+
+// AddChild attaches child to o, detaching it from its previous parent first.
+func (o *Object) AddChild(child *Object) {
+	Assert(child != o && !child.IsAncestorOf(o), "an object can not be its own ancestor")
+
+	child.Detach()
+	if child.scene != nil {
+		// a child is drawn through its parent, not as a root of the scene
+		child.scene.removeRoot(child)
+	}
+	child.Parent = o
+	o.Children = append(o.Children, child)
+}
+
+// RemoveChild detaches child from o, it is a no-op if child is not a child of o.
+func (o *Object) RemoveChild(child *Object) {
+	for i, c := range o.Children {
+		if c == child {
+			o.Children = append(o.Children[:i], o.Children[i+1:]...)
+			child.Parent = nil
+			break
+		}
+	}
+}
+
+// Detach removes the object from its parent, making it a root object.
+func (o *Object) Detach() {
+	if o.Parent != nil {
+		o.Parent.RemoveChild(o)
+	}
+}
+
+// SetParent reparents the object, a nil parent detaches it.
+func (o *Object) SetParent(parent *Object) {
+	if parent == nil {
+		o.Detach()
+		return
+	}
+	parent.AddChild(o)
+}
+
+func (o *Object) IsAncestorOf(other *Object) bool {
+	for p := other.Parent; p != nil; p = p.Parent {
+		if p == o {
+			return true
+		}
+	}
+	return false
+}
+
+// Traverse walks the object and its descendants depth first.
+// Returning false from fn skips the children of that object.
+func (o *Object) Traverse(fn func(*Object) bool) {
+	if !fn(o) {
+		return
+	}
+	for _, child := range o.Children {
+		child.Traverse(fn)
+	}
+}
+
+// Find looks up a descendant by a slash separated path of names relative to o,
+// e.g. "turret/barrel". It returns nil if no such object exists.
+func (o *Object) Find(path string) *Object {
+	return findObject(o.Children, strings.Split(strings.Trim(path, "/"), "/"))
+}
+
+// Path returns the slash separated names from the root of the hierarchy to o.
+func (o *Object) Path() string {
+	if o.Parent == nil {
+		return o.Name
+	}
+	return o.Parent.Path() + "/" + o.Name
+}
+
+func findObject(objects []*Object, names []string) *Object {
+	for _, obj := range objects {
+		if obj.Name != names[0] {
+			continue
+		}
+		if len(names) == 1 {
+			return obj
+		}
+		if found := findObject(obj.Children, names[1:]); found != nil {
+			return found
+		}
+	}
+	return nil
 }
 
 func (o *Object) Translate(x, y, z float32) {
@@ -84,12 +302,21 @@ func (o *Object) RemoveTexture(tex Texture) {
 	}
 }
 
+// SetShader replaces the object's shader. The previous one is released and
+// deleted if no other object or material uses it.
 func (o *Object) SetShader(shader Shader) {
-	o.Shader.Delete()
+	shader.Retain()
+	o.Shader.Release()
 	o.Shader = shader
 }
 
+// Delete frees the object's GPU resources along with those of its children.
 func (o *Object) Delete() {
-	o.Shader.Delete()
-	o.Mesh.Delete()
+	for _, child := range o.Children {
+		child.Delete()
+	}
+	o.Shader.Release()
+	if o.Mesh != nil {
+		o.Mesh.Delete()
+	}
 }
@@ -0,0 +1,447 @@
+package noor
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+
+	"github.com/go-gl/gl/v4.6-core/gl"
+)
+
+//go:embed assets/shaders/post/fullscreen.vert
+var PostVertexShader string
+
+var (
+	//go:embed assets/shaders/post/tonemap.frag
+	tonemapShader string
+	//go:embed assets/shaders/post/gamma.frag
+	gammaShader string
+	//go:embed assets/shaders/post/fxaa.frag
+	fxaaShader string
+	//go:embed assets/shaders/post/vignette.frag
+	vignetteShader string
+	//go:embed assets/shaders/post/lut.frag
+	lutShader string
+	//go:embed assets/shaders/post/blur.frag
+	blurShader string
+	//go:embed assets/shaders/post/bloom_threshold.frag
+	bloomThresholdShader string
+	//go:embed assets/shaders/post/bloom_composite.frag
+	bloomCompositeShader string
+)
+
+// PostEffect is a full screen pass of a PostStack.
+type PostEffect interface {
+	// Apply draws input into output. The output of the last pass is the
+	// window, a framebuffer with a zero Handle and no textures.
+	Apply(input *Texture, output *Framebuffer) error
+	Delete()
+}
+
+type PostPass struct {
+	Name    string
+	Enabled bool
+	Effect  PostEffect
+}
+
+// PostStack renders a scene into an offscreen buffer and runs it through its
+// enabled passes in order, ping-ponging between two buffers, the last pass
+// drawing to the output. A typical HDR chain is bloom, tonemap, gamma, FXAA.
+type PostStack struct {
+	Passes []*PostPass
+
+	// Output receives the last pass, the window when nil.
+	Output *Framebuffer
+
+	format TextureFormat
+	scene  *Framebuffer
+	ping   [2]*Framebuffer
+	window Framebuffer
+}
+
+// NewPostStack creates a stack rendering at width by height. A zero format
+// uses FormatRGBA16F, so colors above 1 survive until tonemapping.
+func NewPostStack(width, height int32, format TextureFormat) (*PostStack, error) {
+	if format == 0 {
+		format = FormatRGBA16F
+	}
+	p := &PostStack{format: format}
+	if err := p.Resize(width, height); err != nil {
+		p.Delete()
+		return nil, fmt.Errorf("failed to create post processing stack: %w", err)
+	}
+	return p, nil
+}
+
+// Add appends an enabled pass running effect.
+func (p *PostStack) Add(name string, effect PostEffect) *PostPass {
+	pass := &PostPass{Name: name, Enabled: true, Effect: effect}
+	p.Passes = append(p.Passes, pass)
+	return pass
+}
+
+// Pass returns the pass called name, or nil.
+func (p *PostStack) Pass(name string) *PostPass {
+	for _, pass := range p.Passes {
+		if pass.Name == name {
+			return pass
+		}
+	}
+	return nil
+}
+
+// SetEnabled turns the pass called name on or off. It reports whether the pass exists.
+func (p *PostStack) SetEnabled(name string, enabled bool) bool {
+	pass := p.Pass(name)
+	if pass != nil {
+		pass.Enabled = enabled
+	}
+	return pass != nil
+}
+
+// Resize resizes the buffers to width by height, usually the window's framebuffer size.
+func (p *PostStack) Resize(width, height int32) error {
+	if p.scene != nil && p.window.Width == width && p.window.Height == height {
+		return nil
+	}
+	p.window.Width, p.window.Height = width, height
+
+	var err error
+	if p.scene == nil {
+		p.scene, err = NewFramebuffer(width, height, FramebufferOptions{
+			Colors: []TextureFormat{p.format},
+			Depth:  DepthRenderbuffer,
+		})
+	} else {
+		err = p.scene.Resize(width, height)
+	}
+	if err != nil {
+		return err
+	}
+
+	for i := range p.ping {
+		if p.ping[i], err = postTarget(p.ping[i], width, height, p.format); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Render draws the scene through the enabled passes. A pass that fails is
+// reported and disabled.
+func (p *PostStack) Render(s *Scene) {
+	output := p.Output
+	if output == nil {
+		output = &p.window
+	}
+
+	var passes []*PostPass
+	for _, pass := range p.Passes {
+		if pass.Enabled && pass.Effect != nil {
+			passes = append(passes, pass)
+		}
+	}
+
+	if len(passes) == 0 {
+		output.Bind()
+		clearBuffers()
+		s.Render()
+		return
+	}
+
+	p.scene.Render(s)
+
+	input := p.scene.Colors[0]
+	// next is the ping buffer free for writing, never the one input is in
+	next := 0
+	for i, pass := range passes {
+		last := i == len(passes)-1
+		target := output
+		if !last {
+			target = p.ping[next]
+		}
+
+		if err := pass.Effect.Apply(input, target); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning : post pass %s disabled: %v\n", pass.Name, err)
+			pass.Enabled = false
+			// keep the chain going with the previous image
+			if last {
+				copyTexture(input, target)
+			}
+			continue
+		}
+		if !last {
+			input = target.Colors[0]
+			next = 1 - next
+		}
+	}
+}
+
+// Delete deletes the buffers and the effects of every pass.
+func (p *PostStack) Delete() {
+	for _, pass := range p.Passes {
+		if pass.Effect != nil {
+			pass.Effect.Delete()
+		}
+	}
+	p.Passes = nil
+
+	for _, fb := range append([]*Framebuffer{p.scene}, p.ping[:]...) {
+		if fb != nil {
+			fb.Delete()
+		}
+	}
+	p.scene = nil
+	p.ping = [2]*Framebuffer{}
+}
+
+// postTarget returns fb holding one color texture of format at width by
+// height, creating or resizing it as needed.
+func postTarget(fb *Framebuffer, width, height int32, format TextureFormat) (*Framebuffer, error) {
+	if fb != nil && fb.Colors[0].Format == format {
+		return fb, fb.Resize(width, height)
+	}
+	if fb != nil {
+		fb.Delete()
+	}
+	return NewFramebuffer(width, height, FramebufferOptions{Colors: []TextureFormat{format}})
+}
+
+// ShaderEffect draws a fragment shader over the whole output. The shader
+// reads the previous pass from `uniform sampler2D uInput` at `in vec2 vUv`
+// and may declare `uniform vec2 uResolution`, the output size in pixels.
+// Its uniforms and extra textures are set like those of a material.
+type ShaderEffect struct {
+	*Material
+}
+
+// NewShaderEffect compiles a custom pass from fragment shader source, run
+// with PostVertexShader.
+func NewShaderEffect(name, fragmentSource string) Result[*ShaderEffect] {
+	shader := SharedShaderProgram(PostVertexShader, fragmentSource)
+	if shader.IsErr() {
+		return Err[*ShaderEffect](fmt.Errorf("failed to create post effect %s: %w", name, shader.Err))
+	}
+
+	mat := NewMaterial(name, shader.Ok)
+	mat.State = RenderState{NoDepthTest: true, NoDepthWrite: true}
+	return Ok(&ShaderEffect{Material: mat})
+}
+
+func (e *ShaderEffect) Apply(input *Texture, output *Framebuffer) error {
+	e.draw(input, output, nil)
+	return checkGLError("applying post effect " + e.Name)
+}
+
+// draw runs the shader into output, calling setup for extra uniforms.
+func (e *ShaderEffect) draw(input *Texture, output *Framebuffer, setup func(sh Shader)) {
+	output.Bind()
+	unit := e.Material.Apply()
+	sh := e.Shader
+	input.Activate(sh, unit, "uInput")
+	if sh.HasUniform("uResolution") {
+		sh.SetUniformVec2("uResolution", float32(output.Width), float32(output.Height))
+	}
+	if setup != nil {
+		setup(sh)
+	}
+	drawFullscreen()
+}
+
+var fullscreenVAO uint32
+
+// drawFullscreen draws the triangle of PostVertexShader, which needs no vertex data.
+func drawFullscreen() {
+	if fullscreenVAO == 0 {
+		gl.GenVertexArrays(1, &fullscreenVAO)
+	}
+	gl.BindVertexArray(fullscreenVAO)
+	gl.DrawArrays(gl.TRIANGLES, 0, 3)
+	gl.BindVertexArray(0)
+}
+
+// copyTexture stands in for a failed last pass so the output is not left empty.
+func copyTexture(input *Texture, output *Framebuffer) {
+	var read uint32
+	gl.CreateFramebuffers(1, &read)
+	gl.NamedFramebufferTexture(read, gl.COLOR_ATTACHMENT0, input.Handle, 0)
+	gl.NamedFramebufferReadBuffer(read, gl.COLOR_ATTACHMENT0)
+	gl.BlitNamedFramebuffer(read, output.Handle, 0, 0, input.Width, input.Height, 0, 0, output.Width, output.Height, gl.COLOR_BUFFER_BIT, gl.LINEAR)
+	gl.DeleteFramebuffers(1, &read)
+}
+
+type ToneMapping int32
+
+const (
+	ToneMappingACES ToneMapping = iota
+	ToneMappingReinhard
+)
+
+// NewTonemapEffect maps HDR colors to 0..1 after scaling them by exposure.
+func NewTonemapEffect(operator ToneMapping, exposure float32) Result[*ShaderEffect] {
+	e := NewShaderEffect("tonemap", tonemapShader)
+	if e.IsOk() {
+		e.Ok.SetInt("uOperator", int32(operator))
+		e.Ok.SetFloat("uExposure", exposure)
+	}
+	return e
+}
+
+// NewGammaEffect converts linear colors for display, usually with a gamma of 2.2.
+func NewGammaEffect(gamma float32) Result[*ShaderEffect] {
+	e := NewShaderEffect("gamma", gammaShader)
+	if e.IsOk() {
+		e.Ok.SetFloat("uGamma", gamma)
+	}
+	return e
+}
+
+// NewFXAAEffect smooths aliased edges. It works best last, on gamma corrected colors.
+func NewFXAAEffect() Result[*ShaderEffect] {
+	return NewShaderEffect("fxaa", fxaaShader)
+}
+
+// NewVignetteEffect darkens the corners. uRadius and uSoftness shape the
+// falloff and default to 0.75 and 0.45.
+func NewVignetteEffect(intensity float32) Result[*ShaderEffect] {
+	e := NewShaderEffect("vignette", vignetteShader)
+	if e.IsOk() {
+		e.Ok.SetFloat("uIntensity", intensity)
+		e.Ok.SetFloat("uRadius", 0.75)
+		e.Ok.SetFloat("uSoftness", 0.45)
+	}
+	return e
+}
+
+// NewLUTEffect grades colors through a lookup table: a texture of N slices of
+// N by N pixels side by side, red along x, green along y and blue selecting
+// the slice, e.g. 256x16. It should use Linear filtering, ClampToEdge and no
+// mipmaps. uIntensity blends with the original colors and defaults to 1.
+func NewLUTEffect(lut *Texture) Result[*ShaderEffect] {
+	e := NewShaderEffect("lut", lutShader)
+	if e.IsOk() {
+		e.Ok.SetTexture("uLUT", lut)
+		e.Ok.SetFloat("uIntensity", 1)
+	}
+	return e
+}
+
+// BlurEffect is a separable gaussian blur.
+type BlurEffect struct {
+	Radius float32 // pixels between the taps of the kernel
+	Passes int     // more passes blur wider
+
+	shader *ShaderEffect
+	temp   [2]*Framebuffer
+}
+
+func NewBlurEffect(radius float32, passes int) Result[*BlurEffect] {
+	e := NewShaderEffect("blur", blurShader)
+	if e.IsErr() {
+		return Err[*BlurEffect](e.Err)
+	}
+	return Ok(&BlurEffect{Radius: radius, Passes: passes, shader: e.Ok})
+}
+
+func (b *BlurEffect) Apply(input *Texture, output *Framebuffer) error {
+	var err error
+	for i := range b.temp {
+		if b.temp[i], err = postTarget(b.temp[i], output.Width, output.Height, input.Format); err != nil {
+			return fmt.Errorf("failed to create blur buffer: %w", err)
+		}
+	}
+
+	dx := b.Radius / float32(output.Width)
+	dy := b.Radius / float32(output.Height)
+	passes := max(b.Passes, 1)
+	for i := range passes {
+		b.shader.draw(input, b.temp[0], func(sh Shader) { sh.SetUniformVec2("uDirection", dx, 0) })
+
+		target := output
+		if i < passes-1 {
+			target = b.temp[1]
+		}
+		b.shader.draw(b.temp[0].Colors[0], target, func(sh Shader) { sh.SetUniformVec2("uDirection", 0, dy) })
+		input = b.temp[1].Colors[0]
+	}
+	return checkGLError("applying blur")
+}
+
+func (b *BlurEffect) Delete() {
+	b.shader.Delete()
+	for i, fb := range b.temp {
+		if fb != nil {
+			fb.Delete()
+			b.temp[i] = nil
+		}
+	}
+}
+
+// BloomEffect makes bright areas glow: colors above Threshold are blurred at
+// half resolution and added back with Intensity. It belongs before
+// tonemapping, on HDR colors.
+type BloomEffect struct {
+	Threshold float32
+	Intensity float32
+	Blur      *BlurEffect
+
+	threshold *ShaderEffect
+	composite *ShaderEffect
+	bright    *Framebuffer
+}
+
+func NewBloomEffect(threshold, intensity float32) Result[*BloomEffect] {
+	blur := NewBlurEffect(1.5, 3)
+	if blur.IsErr() {
+		return Err[*BloomEffect](blur.Err)
+	}
+	thresholdPass := NewShaderEffect("bloom threshold", bloomThresholdShader)
+	if thresholdPass.IsErr() {
+		blur.Ok.Delete()
+		return Err[*BloomEffect](thresholdPass.Err)
+	}
+	composite := NewShaderEffect("bloom composite", bloomCompositeShader)
+	if composite.IsErr() {
+		blur.Ok.Delete()
+		thresholdPass.Ok.Delete()
+		return Err[*BloomEffect](composite.Err)
+	}
+
+	return Ok(&BloomEffect{
+		Threshold: threshold,
+		Intensity: intensity,
+		Blur:      blur.Ok,
+		threshold: thresholdPass.Ok,
+		composite: composite.Ok,
+	})
+}
+
+func (b *BloomEffect) Apply(input *Texture, output *Framebuffer) error {
+	var err error
+	b.bright, err = postTarget(b.bright, max(output.Width/2, 1), max(output.Height/2, 1), input.Format)
+	if err != nil {
+		return fmt.Errorf("failed to create bloom buffer: %w", err)
+	}
+
+	b.threshold.SetFloat("uThreshold", b.Threshold)
+	b.threshold.draw(input, b.bright, nil)
+
+	if err := b.Blur.Apply(b.bright.Colors[0], b.bright); err != nil {
+		return err
+	}
+
+	b.composite.SetTexture("uBloom", b.bright.Colors[0])
+	b.composite.SetFloat("uIntensity", b.Intensity)
+	b.composite.draw(input, output, nil)
+	return checkGLError("applying bloom")
+}
+
+func (b *BloomEffect) Delete() {
+	b.Blur.Delete()
+	b.threshold.Delete()
+	b.composite.Delete()
+	if b.bright != nil {
+		b.bright.Delete()
+		b.bright = nil
+	}
+}
@@ -0,0 +1,293 @@
+package noor
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-gl/gl/v4.6-core/gl"
+)
+
+//go:embed assets/shaders/include
+var includeFS embed.FS
+
+// ShaderIncludes holds the built-in files available to #include, e.g.
+// #include <noor/vertex.glsl> for the attribute layout and transform uniforms
+// of the default shader and #include <noor/lights.glsl> for its lights.
+var ShaderIncludes fs.FS = func() fs.FS {
+	sub, err := fs.Sub(includeFS, "assets/shaders/include")
+	Assert(err == nil, "embedded shader includes are missing")
+	return sub
+}()
+
+// Preprocessor resolves #include directives and injects #defines into GLSL sources.
+//
+// #include "file" is looked up next to the including file first, then like
+// #include <file> in the search path and finally in ShaderIncludes.
+// Files containing #pragma once are included only once.
+type Preprocessor struct {
+	FS         fs.FS // files are read from the OS when nil
+	SearchPath []string
+}
+
+func NewPreprocessor(fsys fs.FS, searchPath ...string) *Preprocessor {
+	return &Preprocessor{FS: fsys, SearchPath: searchPath}
+}
+
+// ProcessedSource is a preprocessed shader source and the file and line each of
+// its lines came from.
+type ProcessedSource struct {
+	Code  string
+	lines []sourceLine
+}
+
+type sourceLine struct {
+	file string
+	line int
+}
+
+// Origin returns the file and line a line of Code came from, counting from 1.
+func (s *ProcessedSource) Origin(line int) (file string, originalLine int, ok bool) {
+	if line < 1 || line > len(s.lines) {
+		return "", 0, false
+	}
+	l := s.lines[line-1]
+	return l.file, l.line, true
+}
+
+// driver log locations: "0:12(3): error" (Mesa), "0(12) : error" (NVIDIA),
+// "ERROR: 0:12: ..." (AMD)
+var logLocation = regexp.MustCompile(`(?m)^((?:ERROR|WARNING): )?\d+(?::(\d+)|\((\d+)\))`)
+
+// MapLog rewrites the locations of a driver info log to the original files and lines.
+func (s *ProcessedSource) MapLog(log string) string {
+	return logLocation.ReplaceAllStringFunc(log, func(match string) string {
+		groups := logLocation.FindStringSubmatch(match)
+		lineText := groups[2] + groups[3]
+		line, err := strconv.Atoi(lineText)
+		if err != nil {
+			return match
+		}
+		file, originalLine, ok := s.Origin(line)
+		if !ok {
+			return match
+		}
+		return fmt.Sprintf("%s%s:%d", groups[1], file, originalLine)
+	})
+}
+
+// ProcessFile preprocesses a shader file, see Process.
+func (p *Preprocessor) ProcessFile(name string, defines map[string]string) (*ProcessedSource, error) {
+	source, err := p.readFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read shader file: %w", err)
+	}
+	return p.Process(name, string(source), defines)
+}
+
+// Process expands the includes of source and adds a #define for every entry of
+// defines right after the #version line, in sorted order. An empty value
+// defines the name without a value. name is used to resolve relative includes
+// and in error locations.
+func (p *Preprocessor) Process(name, source string, defines map[string]string) (*ProcessedSource, error) {
+	e := expansion{p: p, once: map[string]bool{}}
+	if err := e.expand(name, source, nil); err != nil {
+		return nil, err
+	}
+
+	var defineLines []string
+	var defineOrigins []sourceLine
+	for _, key := range slices.Sorted(maps.Keys(defines)) {
+		defineLines = append(defineLines, strings.TrimSpace("#define "+key+" "+defines[key]))
+		defineOrigins = append(defineOrigins, sourceLine{file: "<define " + key + ">", line: 1})
+	}
+
+	at := 0
+	if i := slices.IndexFunc(e.code, func(line string) bool {
+		return strings.HasPrefix(strings.TrimSpace(line), "#version")
+	}); i >= 0 {
+		at = i + 1
+	}
+	e.code = slices.Insert(e.code, at, defineLines...)
+	e.lines = slices.Insert(e.lines, at, defineOrigins...)
+
+	return &ProcessedSource{Code: strings.Join(e.code, "\n") + "\n", lines: e.lines}, nil
+}
+
+type expansion struct {
+	p     *Preprocessor
+	once  map[string]bool
+	code  []string
+	lines []sourceLine
+}
+
+func (e *expansion) expand(name, source string, stack []string) error {
+	if slices.Contains(stack, name) {
+		return fmt.Errorf("include cycle: %s -> %s", strings.Join(stack, " -> "), name)
+	}
+	stack = append(stack, name)
+
+	for i, line := range strings.Split(strings.TrimSuffix(source, "\n"), "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		directive := strings.TrimSpace(line)
+
+		switch {
+		case directive == "#pragma once":
+			if e.once[name] {
+				return nil
+			}
+			e.once[name] = true
+			continue
+
+		case strings.HasPrefix(directive, "#include"):
+			target, quoted, err := parseInclude(directive)
+			if err != nil {
+				return fmt.Errorf("%s:%d: %w", name, i+1, err)
+			}
+			resolved, included, err := e.p.resolve(name, target, quoted)
+			if err != nil {
+				return fmt.Errorf("%s:%d: %w", name, i+1, err)
+			}
+			if err := e.expand(resolved, included, stack); err != nil {
+				return err
+			}
+			continue
+		}
+
+		e.code = append(e.code, line)
+		e.lines = append(e.lines, sourceLine{file: name, line: i + 1})
+	}
+	return nil
+}
+
+func parseInclude(directive string) (target string, quoted bool, err error) {
+	arg := strings.TrimSpace(strings.TrimPrefix(directive, "#include"))
+	if len(arg) >= 2 {
+		switch {
+		case arg[0] == '"' && arg[len(arg)-1] == '"':
+			return arg[1 : len(arg)-1], true, nil
+		case arg[0] == '<' && arg[len(arg)-1] == '>':
+			return arg[1 : len(arg)-1], false, nil
+		}
+	}
+	return "", false, fmt.Errorf("malformed #include: %s", directive)
+}
+
+// resolve finds an included file and returns its name and content.
+func (p *Preprocessor) resolve(from, target string, quoted bool) (string, string, error) {
+	var candidates []string
+	if quoted && !strings.HasPrefix(from, "<") {
+		candidates = append(candidates, p.join(p.dir(from), target))
+	}
+	for _, dir := range p.SearchPath {
+		candidates = append(candidates, p.join(dir, target))
+	}
+
+	for _, candidate := range candidates {
+		if source, err := p.readFile(candidate); err == nil {
+			return candidate, string(source), nil
+		}
+	}
+
+	if source, err := fs.ReadFile(ShaderIncludes, target); err == nil {
+		return "<" + target + ">", string(source), nil
+	}
+
+	return "", "", fmt.Errorf("include file not found: %s", target)
+}
+
+func (p *Preprocessor) readFile(name string) ([]byte, error) {
+	if p.FS != nil {
+		return fs.ReadFile(p.FS, name)
+	}
+	return os.ReadFile(name)
+}
+
+func (p *Preprocessor) join(dir, name string) string {
+	if p.FS != nil {
+		return path.Join(dir, name)
+	}
+	return filepath.Join(dir, name)
+}
+
+func (p *Preprocessor) dir(name string) string {
+	if p.FS != nil {
+		return path.Dir(name)
+	}
+	return filepath.Dir(name)
+}
+
+// CreateShaderProgramFromSources links preprocessed sources. Compile errors
+// point to the original files and lines.
+func CreateShaderProgramFromSources(vertex, fragment *ProcessedSource) Result[Shader] {
+	return linkProgram(
+		shaderStage{kind: gl.VERTEX_SHADER, source: vertex.Code, origin: vertex},
+		shaderStage{kind: gl.FRAGMENT_SHADER, source: fragment.Code, origin: fragment},
+	)
+}
+
+// ShaderVariants compiles a pair of shader files once per set of defines,
+// e.g. with and without HAS_NORMAL_MAP, and caches the resulting programs.
+type ShaderVariants struct {
+	Preprocessor *Preprocessor
+	VertexPath   string
+	FragmentPath string
+
+	variants map[string]Shader
+}
+
+func NewShaderVariants(p *Preprocessor, vertexPath, fragmentPath string) *ShaderVariants {
+	return &ShaderVariants{
+		Preprocessor: p,
+		VertexPath:   vertexPath,
+		FragmentPath: fragmentPath,
+		variants:     map[string]Shader{},
+	}
+}
+
+// Get returns the program compiled with defines, compiling it on first use.
+func (v *ShaderVariants) Get(defines map[string]string) Result[Shader] {
+	key := variantKey(defines)
+	if sh, ok := v.variants[key]; ok {
+		return Ok(sh)
+	}
+
+	vertex, err := v.Preprocessor.ProcessFile(v.VertexPath, defines)
+	if err != nil {
+		return Err[Shader](err)
+	}
+	fragment, err := v.Preprocessor.ProcessFile(v.FragmentPath, defines)
+	if err != nil {
+		return Err[Shader](err)
+	}
+
+	result := CreateShaderProgramFromSources(vertex, fragment)
+	if result.IsOk() {
+		v.variants[key] = result.Ok
+	}
+	return result
+}
+
+// Delete deletes every compiled variant.
+func (v *ShaderVariants) Delete() {
+	for key, sh := range v.variants {
+		sh.Delete()
+		delete(v.variants, key)
+	}
+}
+
+func variantKey(defines map[string]string) string {
+	var key strings.Builder
+	for _, name := range slices.Sorted(maps.Keys(defines)) {
+		fmt.Fprintf(&key, "%s=%s\n", name, defines[name])
+	}
+	return key.String()
+}
@@ -0,0 +1,136 @@
+package noor
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestPreprocessorIncludes(t *testing.T) {
+	fsys := fstest.MapFS{
+		"shaders/main.frag":      {Data: []byte("#version 460\n#include \"common.glsl\"\n#include <lib/util.glsl>\nvoid main() {}\n")},
+		"shaders/common.glsl":    {Data: []byte("#pragma once\n#include <lib/util.glsl>\nfloat common;\n")},
+		"include/lib/util.glsl":  {Data: []byte("#pragma once\nfloat util;\n")},
+		"shaders/lib/util.glsl":  {Data: []byte("float shadowed;\n")},
+		"shaders/uses_noor.frag": {Data: []byte("#include <noor/lights.glsl>\n")},
+	}
+	p := NewPreprocessor(fsys, "include")
+
+	processed, err := p.ProcessFile("shaders/main.frag", map[string]string{"B": "2", "A": ""})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// defines follow #version in sorted order, util.glsl is included once and
+	// <...> includes are not looked up next to the including file
+	want := "#version 460\n#define A\n#define B 2\nfloat util;\nfloat common;\nvoid main() {}\n"
+	if processed.Code != want {
+		t.Errorf("got\n%s\nwant\n%s", processed.Code, want)
+	}
+
+	origins := []struct {
+		line int
+		file string
+		orig int
+	}{
+		{1, "shaders/main.frag", 1},
+		{2, "<define A>", 1},
+		{4, "include/lib/util.glsl", 2},
+		{5, "shaders/common.glsl", 3},
+		{6, "shaders/main.frag", 4},
+	}
+	for _, o := range origins {
+		file, line, ok := processed.Origin(o.line)
+		if !ok || file != o.file || line != o.orig {
+			t.Errorf("line %d comes from %s:%d, want %s:%d", o.line, file, line, o.file, o.orig)
+		}
+	}
+	if _, _, ok := processed.Origin(7); ok {
+		t.Error("a line past the end has an origin")
+	}
+
+	// the built-in includes are found when nothing in the search path matches
+	builtin, err := p.ProcessFile("shaders/uses_noor.frag", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(builtin.Code, "struct Light") {
+		t.Errorf("<noor/lights.glsl> was not expanded:\n%s", builtin.Code)
+	}
+	if file, _, _ := builtin.Origin(1); file != "<noor/lights.glsl>" {
+		t.Errorf("built-in include lines come from %q", file)
+	}
+}
+
+func TestPreprocessorErrors(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.glsl": {Data: []byte("#include \"b.glsl\"\n")},
+		"b.glsl": {Data: []byte("\n#include \"a.glsl\"\n")},
+	}
+	tests := []struct {
+		name, source, want string
+	}{
+		{"cycle", "#include \"a.glsl\"\n", "include cycle: main.frag -> a.glsl -> b.glsl -> a.glsl"},
+		{"missing", "void f();\n#include \"missing.glsl\"\n", "main.frag:2: include file not found: missing.glsl"},
+		{"malformed", "#include missing.glsl\n", "main.frag:1: malformed #include"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := NewPreprocessor(fsys).Process("main.frag", test.source, nil)
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("got error %v, want %q", err, test.want)
+			}
+		})
+	}
+}
+
+func TestMapLog(t *testing.T) {
+	fsys := fstest.MapFS{"lib.glsl": {Data: []byte("float a;\nfloat b;\n")}}
+	processed, err := NewPreprocessor(fsys).Process("main.frag", "#version 460\n#include \"lib.glsl\"\nvoid main() {}\n", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct{ log, want string }{
+		{"0:3(5): error: b undeclared", "lib.glsl:2(5): error: b undeclared"},
+		{"0(4) : error C0000: syntax error", "main.frag:3 : error C0000: syntax error"},
+		{"ERROR: 0:2: 'a' : redefinition", "ERROR: lib.glsl:1: 'a' : redefinition"},
+		{"0:99(1): error: past the end", "0:99(1): error: past the end"},
+	}
+	for _, test := range tests {
+		if got := processed.MapLog(test.log); got != test.want {
+			t.Errorf("MapLog(%q) = %q, want %q", test.log, got, test.want)
+		}
+	}
+}
+
+func TestDefaultShadersExpandIncludes(t *testing.T) {
+	for name, code := range map[string]string{"vertex": DefaultVertexShader, "fragment": DefaultFragmentShader} {
+		if strings.Contains(code, "#include") {
+			t.Errorf("the default %s shader still has an #include", name)
+		}
+		if !strings.HasPrefix(code, "#version") {
+			t.Errorf("the default %s shader does not start with #version", name)
+		}
+	}
+	if !strings.Contains(DefaultVertexShader, "uniform mat4 uModel;") {
+		t.Error("the default vertex shader misses <noor/vertex.glsl>")
+	}
+	if !strings.Contains(DefaultFragmentShader, "uniform Light uLights[MAX_LIGHTS];") {
+		t.Error("the default fragment shader misses <noor/lights.glsl>")
+	}
+
+	sized := expandDefaultShader("default.frag", defaultFragmentSource, map[string]string{"MAX_LIGHTS": "3"})
+	if line := strings.Split(sized.Code, "\n")[1]; line != "#define MAX_LIGHTS 3" {
+		t.Errorf("line 2 of the sized fragment shader is %q, want the MAX_LIGHTS define", line)
+	}
+
+	// driver errors in the default shaders point into the include files
+	lightCount := strings.Index(sized.Code, "uniform int uLightCount;")
+	line := strings.Count(sized.Code[:lightCount], "\n") + 1
+	log := sized.MapLog(fmt.Sprintf("0:%d(13): error: example", line))
+	if !strings.HasPrefix(log, "<noor/lights.glsl>:") {
+		t.Errorf("an error on uLightCount maps to %q, want <noor/lights.glsl>", log)
+	}
+}
@@ -0,0 +1,347 @@
+package noor
+
+import (
+	"math"
+
+	"github.com/ahmedsat/madar"
+)
+
+// Geometry is vertex and index data for a mesh that has not been uploaded yet.
+// Triangles wind counter-clockwise when seen from outside the shape.
+type Geometry struct {
+	Vertices []Vertex
+	Indices  []uint32
+}
+
+// Mesh uploads the geometry as a triangle mesh.
+func (g Geometry) Mesh() *Mesh {
+	return NewMesh(g.Vertices, g.Indices, DrawTriangles)
+}
+
+var defaultVertexColor = [3]float32{1, 1, 1}
+
+// PlaneGeometry is a width × depth plane on the XZ plane facing +Y.
+func PlaneGeometry(width, depth float32) Geometry {
+	return GridGeometry(width, depth, 1, 1)
+}
+
+func NewPlaneMesh(width, depth float32) *Mesh {
+	return PlaneGeometry(width, depth).Mesh()
+}
+
+// GridGeometry is a plane subdivided into columns × rows quads, facing +Y.
+func GridGeometry(width, depth float32, columns, rows int) Geometry {
+	columns, rows = max(columns, 1), max(rows, 1)
+	return surface(columns, rows, func(col, row int) Vertex {
+		u, v := float32(col)/float32(columns), float32(row)/float32(rows)
+		return Vertex{
+			Position: [3]float32{(u - 0.5) * width, 0, (0.5 - v) * depth},
+			Color:    defaultVertexColor,
+			UV:       [2]float32{u, v},
+			Normal:   [3]float32{0, 1, 0},
+		}
+	})
+}
+
+func NewGridMesh(width, depth float32, columns, rows int) *Mesh {
+	return GridGeometry(width, depth, columns, rows).Mesh()
+}
+
+// CubeGeometry is an axis aligned box centered at the origin, with separate
+// vertices per face so each face has its own normal and full 0..1 UVs.
+func CubeGeometry(width, height, depth float32) Geometry {
+	g := Geometry{}
+	x := madar.Vector3{X: width / 2}
+	y := madar.Vector3{Y: height / 2}
+	z := madar.Vector3{Z: depth / 2}
+
+	// each face is given by its center offset and two axes whose cross product faces outwards
+	faces := [][3]madar.Vector3{
+		{z, x, y},
+		{scale(z, -1), scale(x, -1), y},
+		{x, scale(z, -1), y},
+		{scale(x, -1), z, y},
+		{y, x, scale(z, -1)},
+		{scale(y, -1), x, z},
+	}
+
+	for _, f := range faces {
+		center, u, v := f[0], f[1], f[2]
+		normal := fromVector3(normalize(center))
+		base := uint32(len(g.Vertices))
+
+		corners := [4]madar.Vector3{
+			sub(sub(center, u), v),
+			sub(add(center, u), v),
+			add(add(center, u), v),
+			add(sub(center, u), v),
+		}
+		uvs := [4][2]float32{{0, 0}, {1, 0}, {1, 1}, {0, 1}}
+
+		for i, c := range corners {
+			g.Vertices = append(g.Vertices, Vertex{Position: fromVector3(c), Color: defaultVertexColor, UV: uvs[i], Normal: normal})
+		}
+		g.Indices = append(g.Indices, base, base+1, base+2, base+2, base+3, base)
+	}
+
+	return g
+}
+
+func NewCubeMesh(size float32) *Mesh {
+	return CubeGeometry(size, size, size).Mesh()
+}
+
+// UVSphereGeometry is a sphere made of segments around the Y axis and rings from pole to pole.
+func UVSphereGeometry(radius float32, segments, rings int) Geometry {
+	segments, rings = max(segments, 3), max(rings, 2)
+	return surface(segments, rings, func(col, row int) Vertex {
+		u, v := float32(col)/float32(segments), float32(row)/float32(rings)
+		n := sphereNormal(u*2*math.Pi, v*math.Pi)
+		return Vertex{
+			Position: fromVector3(scale(n, radius)),
+			Color:    defaultVertexColor,
+			UV:       [2]float32{u, 1 - v},
+			Normal:   fromVector3(n),
+		}
+	})
+}
+
+func NewUVSphereMesh(radius float32, segments, rings int) *Mesh {
+	return UVSphereGeometry(radius, segments, rings).Mesh()
+}
+
+// IcosphereGeometry is a sphere built by subdividing an icosahedron, giving evenly
+// sized triangles. UVs use a spherical projection, so there is a seam along -X.
+func IcosphereGeometry(radius float32, subdivisions int) Geometry {
+	t := float32((1 + math.Sqrt(5)) / 2)
+	positions := []madar.Vector3{
+		{X: -1, Y: t}, {X: 1, Y: t}, {X: -1, Y: -t}, {X: 1, Y: -t},
+		{Y: -1, Z: t}, {Y: 1, Z: t}, {Y: -1, Z: -t}, {Y: 1, Z: -t},
+		{X: t, Z: -1}, {X: t, Z: 1}, {X: -t, Z: -1}, {X: -t, Z: 1},
+	}
+	for i := range positions {
+		positions[i] = normalize(positions[i])
+	}
+
+	triangles := [][3]uint32{
+		{0, 11, 5}, {0, 5, 1}, {0, 1, 7}, {0, 7, 10}, {0, 10, 11},
+		{1, 5, 9}, {5, 11, 4}, {11, 10, 2}, {10, 7, 6}, {7, 1, 8},
+		{3, 9, 4}, {3, 4, 2}, {3, 2, 6}, {3, 6, 8}, {3, 8, 9},
+		{4, 9, 5}, {2, 4, 11}, {6, 2, 10}, {8, 6, 7}, {9, 8, 1},
+	}
+
+	for s := 0; s < subdivisions; s++ {
+		midpoints := map[[2]uint32]uint32{}
+		midpoint := func(a, b uint32) uint32 {
+			key := [2]uint32{min(a, b), max(a, b)}
+			if i, ok := midpoints[key]; ok {
+				return i
+			}
+			i := uint32(len(positions))
+			positions = append(positions, normalize(scale(add(positions[a], positions[b]), 0.5)))
+			midpoints[key] = i
+			return i
+		}
+
+		next := make([][3]uint32, 0, len(triangles)*4)
+		for _, tri := range triangles {
+			ab, bc, ca := midpoint(tri[0], tri[1]), midpoint(tri[1], tri[2]), midpoint(tri[2], tri[0])
+			next = append(next,
+				[3]uint32{tri[0], ab, ca},
+				[3]uint32{tri[1], bc, ab},
+				[3]uint32{tri[2], ca, bc},
+				[3]uint32{ab, bc, ca},
+			)
+		}
+		triangles = next
+	}
+
+	g := Geometry{Vertices: make([]Vertex, len(positions))}
+	for i, n := range positions {
+		g.Vertices[i] = Vertex{
+			Position: fromVector3(scale(n, radius)),
+			Color:    defaultVertexColor,
+			UV: [2]float32{
+				0.5 + float32(math.Atan2(float64(n.Z), float64(n.X))/(2*math.Pi)),
+				0.5 + float32(math.Asin(float64(n.Y))/math.Pi),
+			},
+			Normal: fromVector3(n),
+		}
+	}
+	for _, tri := range triangles {
+		g.Indices = append(g.Indices, tri[0], tri[1], tri[2])
+	}
+	return g
+}
+
+func NewIcosphereMesh(radius float32, subdivisions int) *Mesh {
+	return IcosphereGeometry(radius, subdivisions).Mesh()
+}
+
+// CylinderGeometry is a capped cylinder along the Y axis centered at the origin.
+func CylinderGeometry(radius, height float32, segments int) Geometry {
+	segments = max(segments, 3)
+	g := surface(segments, 1, func(col, row int) Vertex {
+		u, v := float32(col)/float32(segments), float32(row)
+		n := sphereNormal(u*2*math.Pi, math.Pi/2)
+		return Vertex{
+			Position: [3]float32{n.X * radius, (0.5 - v) * height, n.Z * radius},
+			Color:    defaultVertexColor,
+			UV:       [2]float32{u, 1 - v},
+			Normal:   fromVector3(n),
+		}
+	})
+	g.addCap(radius, height/2, segments, true)
+	g.addCap(radius, -height/2, segments, false)
+	return g
+}
+
+func NewCylinderMesh(radius, height float32, segments int) *Mesh {
+	return CylinderGeometry(radius, height, segments).Mesh()
+}
+
+// ConeGeometry is a cone along the Y axis with its apex at +height/2 and a capped base.
+func ConeGeometry(radius, height float32, segments int) Geometry {
+	segments = max(segments, 3)
+	slope := normalize(madar.Vector3{X: height, Y: radius})
+	g := surface(segments, 1, func(col, row int) Vertex {
+		u, v := float32(col)/float32(segments), float32(row)
+		ring := sphereNormal(u*2*math.Pi, math.Pi/2)
+		return Vertex{
+			Position: [3]float32{ring.X * radius * v, (0.5 - v) * height, ring.Z * radius * v},
+			Color:    defaultVertexColor,
+			UV:       [2]float32{u, 1 - v},
+			Normal:   [3]float32{ring.X * slope.X, slope.Y, ring.Z * slope.X},
+		}
+	})
+	g.addCap(radius, -height/2, segments, false)
+	return g
+}
+
+func NewConeMesh(radius, height float32, segments int) *Mesh {
+	return ConeGeometry(radius, height, segments).Mesh()
+}
+
+// CapsuleGeometry is a cylinder of the given height with hemispherical ends along
+// the Y axis, so its total height is height + 2*radius.
+func CapsuleGeometry(radius, height float32, segments, rings int) Geometry {
+	segments, rings = max(segments, 3), max(rings, 1)
+	rows := 2*rings + 1 // rings per hemisphere plus the cylinder band between them
+	total := height + 2*radius
+
+	return surface(segments, rows, func(col, row int) Vertex {
+		u := float32(col) / float32(segments)
+
+		var theta, offset float32
+		if row <= rings {
+			theta, offset = float32(row)/float32(rings)*math.Pi/2, height/2
+		} else {
+			theta, offset = math.Pi/2+float32(row-rings-1)/float32(rings)*math.Pi/2, -height/2
+		}
+
+		n := sphereNormal(u*2*math.Pi, theta)
+		p := scale(n, radius)
+		p.Y += offset
+		return Vertex{
+			Position: fromVector3(p),
+			Color:    defaultVertexColor,
+			UV:       [2]float32{u, 0.5 + p.Y/total},
+			Normal:   fromVector3(n),
+		}
+	})
+}
+
+func NewCapsuleMesh(radius, height float32, segments, rings int) *Mesh {
+	return CapsuleGeometry(radius, height, segments, rings).Mesh()
+}
+
+// TorusGeometry is a ring around the Y axis. radius is the distance from the center
+// to the middle of the tube and tubeRadius the radius of the tube itself.
+func TorusGeometry(radius, tubeRadius float32, segments, tubeSegments int) Geometry {
+	segments, tubeSegments = max(segments, 3), max(tubeSegments, 3)
+	return surface(segments, tubeSegments, func(col, row int) Vertex {
+		u, v := float32(col)/float32(segments), float32(row)/float32(tubeSegments)
+		phi := float64(u * 2 * math.Pi)
+		psi := float64(-v * 2 * math.Pi)
+
+		n := madar.Vector3{
+			X: float32(math.Cos(psi) * math.Cos(phi)),
+			Y: float32(math.Sin(psi)),
+			Z: float32(math.Cos(psi) * math.Sin(phi)),
+		}
+		center := madar.Vector3{X: radius * float32(math.Cos(phi)), Z: radius * float32(math.Sin(phi))}
+		return Vertex{
+			Position: fromVector3(add(center, scale(n, tubeRadius))),
+			Color:    defaultVertexColor,
+			UV:       [2]float32{u, v},
+			Normal:   fromVector3(n),
+		}
+	})
+}
+
+func NewTorusMesh(radius, tubeRadius float32, segments, tubeSegments int) *Mesh {
+	return TorusGeometry(radius, tubeRadius, segments, tubeSegments).Mesh()
+}
+
+// surface builds a (columns+1) × (rows+1) grid of vertices and triangulates it.
+// The triangles face outwards when the derivative along columns crossed with the
+// derivative along rows points outwards.
+func surface(columns, rows int, vertex func(col, row int) Vertex) Geometry {
+	g := Geometry{Vertices: make([]Vertex, 0, (columns+1)*(rows+1))}
+
+	for row := 0; row <= rows; row++ {
+		for col := 0; col <= columns; col++ {
+			g.Vertices = append(g.Vertices, vertex(col, row))
+		}
+	}
+
+	stride := uint32(columns + 1)
+	for row := 0; row < rows; row++ {
+		for col := 0; col < columns; col++ {
+			a := uint32(row)*stride + uint32(col)
+			b := a + stride
+			g.Indices = append(g.Indices, a, a+1, b, a+1, b+1, b)
+		}
+	}
+	return g
+}
+
+// addCap adds a disk at height y facing +Y when up is true, -Y otherwise.
+func (g *Geometry) addCap(radius, y float32, segments int, up bool) {
+	normal := [3]float32{0, -1, 0}
+	if up {
+		normal = [3]float32{0, 1, 0}
+	}
+
+	center := uint32(len(g.Vertices))
+	g.Vertices = append(g.Vertices, Vertex{Position: [3]float32{0, y, 0}, Color: defaultVertexColor, UV: [2]float32{0.5, 0.5}, Normal: normal})
+
+	for i := 0; i <= segments; i++ {
+		n := sphereNormal(float32(i)/float32(segments)*2*math.Pi, math.Pi/2)
+		g.Vertices = append(g.Vertices, Vertex{
+			Position: [3]float32{n.X * radius, y, n.Z * radius},
+			Color:    defaultVertexColor,
+			UV:       [2]float32{0.5 + n.X/2, 0.5 + n.Z/2},
+			Normal:   normal,
+		})
+	}
+
+	for i := uint32(1); i <= uint32(segments); i++ {
+		if up {
+			g.Indices = append(g.Indices, center, center+i+1, center+i)
+		} else {
+			g.Indices = append(g.Indices, center, center+i, center+i+1)
+		}
+	}
+}
+
+// sphereNormal returns the unit vector at azimuth phi around the Y axis
+// and polar angle theta measured from +Y.
+func sphereNormal(phi, theta float32) madar.Vector3 {
+	p, t := float64(phi), float64(theta)
+	return madar.Vector3{
+		X: float32(math.Sin(t) * math.Cos(p)),
+		Y: float32(math.Cos(t)),
+		Z: float32(math.Sin(t) * math.Sin(p)),
+	}
+}
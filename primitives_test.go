@@ -0,0 +1,64 @@
+package noor
+
+import "testing"
+
+func TestGeometry(t *testing.T) {
+	tests := []struct {
+		name     string
+		geometry Geometry
+		vertices int
+		indices  int
+		// convex shapes around the origin also face away from it
+		convex bool
+	}{
+		{"plane", PlaneGeometry(2, 2), 4, 6, false},
+		{"grid", GridGeometry(2, 1, 3, 2), 4 * 3, 3 * 2 * 6, false},
+		{"cube", CubeGeometry(1, 2, 3), 6 * 4, 6 * 6, true},
+		{"uv sphere", UVSphereGeometry(1, 8, 4), 9 * 5, 8 * 4 * 6, true},
+		{"icosphere", IcosphereGeometry(1, 1), 42, 80 * 3, true},
+		{"cylinder", CylinderGeometry(1, 2, 8), 9*2 + 2*10, 8*6 + 2*8*3, true},
+		{"cone", ConeGeometry(1, 2, 8), 9*2 + 10, 8*6 + 8*3, true},
+		{"capsule", CapsuleGeometry(0.5, 1, 8, 3), 9 * 8, 8 * 7 * 6, true},
+		{"torus", TorusGeometry(2, 0.5, 8, 6), 9 * 7, 8 * 6 * 6, false},
+		{"clamped segments", UVSphereGeometry(1, 0, 0), 4 * 3, 3 * 2 * 6, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := test.geometry
+			if len(g.Vertices) != test.vertices || len(g.Indices) != test.indices {
+				t.Fatalf("got %d vertices and %d indices, want %d and %d", len(g.Vertices), len(g.Indices), test.vertices, test.indices)
+			}
+
+			for i, v := range g.Vertices {
+				if v.UV[0] < -1e-6 || v.UV[0] > 1+1e-6 || v.UV[1] < -1e-6 || v.UV[1] > 1+1e-6 {
+					t.Errorf("vertex %d has UV %v outside of 0..1", i, v.UV)
+				}
+			}
+
+			for i := 0; i < len(g.Indices); i += 3 {
+				tri := [3]uint32{g.Indices[i], g.Indices[i+1], g.Indices[i+2]}
+				for _, index := range tri {
+					if int(index) >= len(g.Vertices) {
+						t.Fatalf("triangle %d has out of range index %d", i/3, index)
+					}
+				}
+
+				a, b, c := g.Vertices[tri[0]], g.Vertices[tri[1]], g.Vertices[tri[2]]
+				pa, pb, pc := toVector3(a.Position), toVector3(b.Position), toVector3(c.Position)
+				face := cross(sub(pb, pa), sub(pc, pa))
+				if length(face) < 1e-6 {
+					continue // collapsed at a pole or an apex
+				}
+
+				normals := add(add(toVector3(a.Normal), toVector3(b.Normal)), toVector3(c.Normal))
+				if dot(face, normals) <= 0 {
+					t.Errorf("triangle %d winds against its vertex normals", i/3)
+				}
+				if centroid := scale(add(add(pa, pb), pc), 1.0/3); test.convex && dot(face, centroid) <= 0 {
+					t.Errorf("triangle %d faces inwards", i/3)
+				}
+			}
+		})
+	}
+}
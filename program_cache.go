@@ -0,0 +1,112 @@
+package noor
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/go-gl/gl/v4.6-core/gl"
+)
+
+// ProgramCacheDir is where linked program binaries are stored, so later runs
+// can skip compiling shaders. Entries are keyed by the shader sources and the
+// GL vendor, renderer and version, and are recompiled transparently when the
+// driver rejects them. An empty ProgramCacheDir disables the cache.
+var ProgramCacheDir = defaultProgramCacheDir()
+
+func defaultProgramCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "noor", "programs")
+}
+
+var driverID string
+
+// driverIdentity identifies the driver that produced a program binary.
+func driverIdentity() string {
+	if driverID == "" {
+		driverID = fmt.Sprintf("%s\n%s\n%s",
+			gl.GoStr(gl.GetString(gl.VENDOR)),
+			gl.GoStr(gl.GetString(gl.RENDERER)),
+			gl.GoStr(gl.GetString(gl.VERSION)),
+		)
+	}
+	return driverID
+}
+
+func programCacheEnabled() bool {
+	if ProgramCacheDir == "" {
+		return false
+	}
+	var formats int32
+	gl.GetIntegerv(gl.NUM_PROGRAM_BINARY_FORMATS, &formats)
+	return formats > 0
+}
+
+func programCachePath(stages []shaderStage) string {
+	hash := sha256.New()
+	fmt.Fprintln(hash, driverIdentity())
+	for _, stage := range stages {
+		fmt.Fprintf(hash, "%d %d\n%s", stage.kind, len(stage.source), stage.source)
+	}
+	return filepath.Join(ProgramCacheDir, hex.EncodeToString(hash.Sum(nil))+".bin")
+}
+
+// loadProgramBinary loads a cached binary into program and reports whether it linked.
+func loadProgramBinary(program uint32, path string) bool {
+	data, err := os.ReadFile(path)
+	if err != nil || len(data) <= 4 {
+		return false
+	}
+
+	format := binary.LittleEndian.Uint32(data)
+	binaryData := data[4:]
+	gl.ProgramBinary(program, format, gl.Ptr(binaryData), int32(len(binaryData)))
+
+	var status int32
+	gl.GetProgramiv(program, gl.LINK_STATUS, &status)
+	if status == gl.FALSE {
+		// driver update or foreign binary, it will be overwritten after compiling
+		os.Remove(path)
+		// an unknown format raises GL_INVALID_ENUM, don't let it surface in later error checks
+		for gl.GetError() != gl.NO_ERROR {
+		}
+		return false
+	}
+	return true
+}
+
+// storeProgramBinary writes the binary of a linked program to path.
+func storeProgramBinary(program uint32, path string) error {
+	var length int32
+	gl.GetProgramiv(program, gl.PROGRAM_BINARY_LENGTH, &length)
+	if length == 0 {
+		return errors.New("driver returned an empty program binary")
+	}
+
+	data := make([]byte, 4+length)
+	var format uint32
+	gl.GetProgramBinary(program, length, nil, &format, gl.Ptr(data[4:]))
+	binary.LittleEndian.PutUint32(data, format)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create program cache directory: %w", err)
+	}
+
+	// write to a temporary file first so a crash never leaves a truncated entry
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write program binary: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write program binary: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,287 @@
+package noor
+
+import (
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"time"
+	"unsafe"
+
+	"github.com/go-gl/gl/v4.6-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+// Image reads color attachment index, top row first. Float formats are
+// clamped to 0..1 and converted to 8 bits, without tonemapping.
+func (fb *Framebuffer) Image(index int) (*image.RGBA, error) {
+	if index < 0 || index >= len(fb.Colors) {
+		return nil, fmt.Errorf("framebuffer has no color attachment %d", index)
+	}
+
+	xtype := readType(fb.Colors[index].Format)
+	img := readImage(fb.Width, fb.Height, xtype, 4, true, func(dst unsafe.Pointer, size int32) {
+		gl.NamedFramebufferReadBuffer(fb.Handle, gl.COLOR_ATTACHMENT0+uint32(index))
+		gl.BindFramebuffer(gl.READ_FRAMEBUFFER, fb.Handle)
+		gl.ReadnPixels(0, 0, fb.Width, fb.Height, gl.RGBA, xtype, size, dst)
+		gl.BindFramebuffer(gl.READ_FRAMEBUFFER, 0)
+		gl.NamedFramebufferReadBuffer(fb.Handle, gl.COLOR_ATTACHMENT0)
+	})
+
+	if err := checkGLError("reading framebuffer"); err != nil {
+		return nil, err
+	}
+	return img, nil
+}
+
+// SavePNG writes color attachment 0 to a PNG file.
+func (fb *Framebuffer) SavePNG(path string) error {
+	img, err := fb.Image(0)
+	if err != nil {
+		return err
+	}
+	return SavePNG(path, img)
+}
+
+// Image reads level 0 of a 2D texture, top row first. BottomUp textures get
+// their rows flipped.
+// Float formats are clamped to 0..1 and depth formats are read as grey.
+func (tex *Texture) Image() (*image.RGBA, error) {
+	if tex.Type != Texture2D {
+		return nil, fmt.Errorf("cannot read texture %s: only 2D textures can be read", tex.Name)
+	}
+
+	format, xtype, channels := uint32(gl.RGBA), readType(tex.Format), 4
+	if tex.Format.IsDepth() {
+		format, xtype, channels = gl.DEPTH_COMPONENT, gl.FLOAT, 1
+	}
+
+	img := readImage(tex.Width, tex.Height, xtype, channels, tex.BottomUp, func(dst unsafe.Pointer, size int32) {
+		gl.GetTextureImage(tex.Handle, 0, format, xtype, size, dst)
+	})
+
+	if err := checkGLError("reading texture " + tex.Name); err != nil {
+		return nil, err
+	}
+	return img, nil
+}
+
+// Screenshot renders the scene offscreen as Loop shows it, post processing
+// included, see RenderImage.
+func (n *Noor) Screenshot() (*image.RGBA, error) {
+	return n.RenderImage()
+}
+
+// SaveScreenshot writes a Screenshot to a PNG file.
+func (n *Noor) SaveScreenshot(path string) error {
+	img, err := n.Screenshot()
+	if err != nil {
+		return err
+	}
+	return SavePNG(path, img)
+}
+
+// SetScreenshotKey makes Loop save the shown frame to dir as a timestamped
+// PNG whenever key is pressed. glfw.KeyUnknown disables it.
+func (n *Noor) SetScreenshotKey(key glfw.Key, dir string) {
+	n.screenshotKey = key
+	n.screenshotDir = dir
+}
+
+// checkScreenshotKey saves the back buffer when the screenshot key goes down.
+// It runs between rendering and SwapBuffers, while the frame is still there.
+func (n *Noor) checkScreenshotKey() {
+	if n.screenshotKey == 0 || n.screenshotKey == glfw.KeyUnknown {
+		return
+	}
+	down := n.Window.GetKey(n.screenshotKey) == glfw.Press
+	pressed := down && !n.screenshotHeld
+	n.screenshotHeld = down
+	if !pressed {
+		return
+	}
+
+	width, height := n.Window.GetFramebufferSize()
+	img := readImage(int32(width), int32(height), gl.UNSIGNED_BYTE, 4, true, func(dst unsafe.Pointer, size int32) {
+		gl.BindFramebuffer(gl.READ_FRAMEBUFFER, 0)
+		gl.ReadBuffer(gl.BACK)
+		gl.ReadnPixels(0, 0, int32(width), int32(height), gl.RGBA, gl.UNSIGNED_BYTE, size, dst)
+	})
+
+	path := filepath.Join(n.screenshotDir, time.Now().Format("screenshot-20060102-150405.000.png"))
+	if err := SavePNG(path, img); err != nil {
+		fmt.Fprintf(os.Stderr, "Error : saving screenshot: %v\n", err)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Screenshot saved to %s\n", path)
+}
+
+// Readback is a framebuffer read running in the background through a pixel
+// buffer, so the CPU does not stall waiting for the GPU. Poll Ready once a
+// frame and call Image when it reports true.
+type Readback struct {
+	pbo           uint32
+	fence         uintptr
+	width, height int32
+	xtype         uint32
+}
+
+// ReadAsync starts reading color attachment index.
+func (fb *Framebuffer) ReadAsync(index int) (*Readback, error) {
+	if index < 0 || index >= len(fb.Colors) {
+		return nil, fmt.Errorf("framebuffer has no color attachment %d", index)
+	}
+
+	r := &Readback{width: fb.Width, height: fb.Height, xtype: readType(fb.Colors[index].Format)}
+	gl.CreateBuffers(1, &r.pbo)
+	gl.NamedBufferData(r.pbo, r.size(), nil, gl.STREAM_READ)
+
+	gl.NamedFramebufferReadBuffer(fb.Handle, gl.COLOR_ATTACHMENT0+uint32(index))
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, fb.Handle)
+	gl.BindBuffer(gl.PIXEL_PACK_BUFFER, r.pbo)
+	gl.PixelStorei(gl.PACK_ALIGNMENT, 1)
+	gl.ReadPixels(0, 0, fb.Width, fb.Height, gl.RGBA, r.xtype, nil)
+	gl.BindBuffer(gl.PIXEL_PACK_BUFFER, 0)
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, 0)
+	gl.NamedFramebufferReadBuffer(fb.Handle, gl.COLOR_ATTACHMENT0)
+
+	r.fence = gl.FenceSync(gl.SYNC_GPU_COMMANDS_COMPLETE, 0)
+	if err := checkGLError("starting framebuffer read"); err != nil {
+		r.Delete()
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *Readback) size() int {
+	if r.xtype == gl.FLOAT {
+		return int(r.width) * int(r.height) * 16
+	}
+	return int(r.width) * int(r.height) * 4
+}
+
+// Ready reports whether the pixels arrived, without waiting.
+func (r *Readback) Ready() bool {
+	if r.fence == 0 {
+		return true
+	}
+	status := gl.ClientWaitSync(r.fence, gl.SYNC_FLUSH_COMMANDS_BIT, 0)
+	return status == gl.ALREADY_SIGNALED || status == gl.CONDITION_SATISFIED
+}
+
+// Image waits for the pixels, returns them top row first and frees the read.
+func (r *Readback) Image() (*image.RGBA, error) {
+	if r.pbo == 0 {
+		return nil, fmt.Errorf("readback already consumed")
+	}
+	defer r.Delete()
+
+	for r.fence != 0 {
+		status := gl.ClientWaitSync(r.fence, gl.SYNC_FLUSH_COMMANDS_BIT, uint64(time.Second))
+		if status == gl.WAIT_FAILED {
+			return nil, fmt.Errorf("failed to wait for framebuffer read")
+		}
+		if status == gl.ALREADY_SIGNALED || status == gl.CONDITION_SATISFIED {
+			break
+		}
+	}
+
+	img := readImage(r.width, r.height, r.xtype, 4, true, func(dst unsafe.Pointer, size int32) {
+		src := gl.MapNamedBufferRange(r.pbo, 0, int(size), gl.MAP_READ_BIT)
+		if src == nil {
+			return
+		}
+		copy(unsafe.Slice((*byte)(dst), size), unsafe.Slice((*byte)(src), size))
+		gl.UnmapNamedBuffer(r.pbo)
+	})
+
+	if err := checkGLError("finishing framebuffer read"); err != nil {
+		return nil, err
+	}
+	return img, nil
+}
+
+func (r *Readback) Delete() {
+	if r.fence != 0 {
+		gl.DeleteSync(r.fence)
+		r.fence = 0
+	}
+	if r.pbo != 0 {
+		gl.DeleteBuffers(1, &r.pbo)
+		r.pbo = 0
+	}
+}
+
+// SavePNG encodes img to a PNG file.
+func SavePNG(path string, img image.Image) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	if err := png.Encode(file, img); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to encode %s: %w", path, err)
+	}
+	return file.Close()
+}
+
+// readType is the type pixels of format are read as: floats for float
+// formats, so values are not quantized by the driver, bytes otherwise.
+func readType(format TextureFormat) uint32 {
+	switch format {
+	case FormatRGBA16F, FormatRGBA32F, FormatRG16F, FormatR32F, FormatDepth32F:
+		return gl.FLOAT
+	}
+	return gl.UNSIGNED_BYTE
+}
+
+// readImage lets read fill a buffer of width by height pixels of channels
+// xtype values and converts it to RGBA, flipping rows when the source starts
+// at the bottom. One channel is expanded to grey.
+func readImage(width, height int32, xtype uint32, channels int, flip bool, read func(dst unsafe.Pointer, size int32)) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, int(width), int(height)))
+	count := int(width) * int(height) * channels
+	if count == 0 {
+		return img
+	}
+
+	gl.PixelStorei(gl.PACK_ALIGNMENT, 1)
+	if xtype == gl.FLOAT {
+		values := make([]float32, count)
+		read(unsafe.Pointer(&values[0]), int32(count*4))
+		for i := range int(width) * int(height) {
+			pixel := img.Pix[i*4 : i*4+4]
+			if channels == 1 {
+				v := toByte(values[i])
+				pixel[0], pixel[1], pixel[2], pixel[3] = v, v, v, 0xff
+				continue
+			}
+			for c := range 4 {
+				pixel[c] = toByte(values[i*4+c])
+			}
+		}
+	} else {
+		read(unsafe.Pointer(&img.Pix[0]), int32(len(img.Pix)))
+	}
+
+	if flip {
+		flipRows(img.Pix, img.Stride)
+	}
+	return img
+}
+
+func toByte(v float32) uint8 {
+	return uint8(min(max(v, 0), 1)*255 + 0.5)
+}
+
+// flipRows reverses the order of the rows of stride bytes in pix, GL images
+// start at the bottom.
+func flipRows(pix []byte, stride int) {
+	row := make([]byte, stride)
+	for top, bottom := 0, len(pix)-stride; top < bottom; top, bottom = top+stride, bottom-stride {
+		copy(row, pix[top:top+stride])
+		copy(pix[top:top+stride], pix[bottom:bottom+stride])
+		copy(pix[bottom:bottom+stride], row)
+	}
+}
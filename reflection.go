@@ -0,0 +1,211 @@
+package noor
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-gl/gl/v4.6-core/gl"
+)
+
+// GLSLType is the GL enum of a uniform or attribute type, e.g. gl.FLOAT_VEC3.
+type GLSLType uint32
+
+var glslTypeNames = map[GLSLType]string{
+	gl.FLOAT: "float", gl.FLOAT_VEC2: "vec2", gl.FLOAT_VEC3: "vec3", gl.FLOAT_VEC4: "vec4",
+	gl.DOUBLE: "double", gl.DOUBLE_VEC2: "dvec2", gl.DOUBLE_VEC3: "dvec3", gl.DOUBLE_VEC4: "dvec4",
+	gl.INT: "int", gl.INT_VEC2: "ivec2", gl.INT_VEC3: "ivec3", gl.INT_VEC4: "ivec4",
+	gl.UNSIGNED_INT: "uint", gl.UNSIGNED_INT_VEC2: "uvec2", gl.UNSIGNED_INT_VEC3: "uvec3", gl.UNSIGNED_INT_VEC4: "uvec4",
+	gl.BOOL: "bool", gl.BOOL_VEC2: "bvec2", gl.BOOL_VEC3: "bvec3", gl.BOOL_VEC4: "bvec4",
+	gl.FLOAT_MAT2: "mat2", gl.FLOAT_MAT3: "mat3", gl.FLOAT_MAT4: "mat4",
+	gl.FLOAT_MAT2x3: "mat2x3", gl.FLOAT_MAT2x4: "mat2x4", gl.FLOAT_MAT3x2: "mat3x2",
+	gl.FLOAT_MAT3x4: "mat3x4", gl.FLOAT_MAT4x2: "mat4x2", gl.FLOAT_MAT4x3: "mat4x3",
+
+	gl.SAMPLER_1D: "sampler1D", gl.SAMPLER_2D: "sampler2D", gl.SAMPLER_3D: "sampler3D",
+	gl.SAMPLER_CUBE: "samplerCube", gl.SAMPLER_2D_SHADOW: "sampler2DShadow",
+	gl.SAMPLER_2D_ARRAY: "sampler2DArray", gl.SAMPLER_2D_ARRAY_SHADOW: "sampler2DArrayShadow",
+	gl.SAMPLER_CUBE_SHADOW: "samplerCubeShadow", gl.SAMPLER_2D_MULTISAMPLE: "sampler2DMS",
+	gl.SAMPLER_BUFFER: "samplerBuffer",
+	gl.INT_SAMPLER_2D: "isampler2D", gl.INT_SAMPLER_3D: "isampler3D",
+	gl.UNSIGNED_INT_SAMPLER_2D: "usampler2D", gl.UNSIGNED_INT_SAMPLER_3D: "usampler3D",
+
+	gl.IMAGE_2D: "image2D", gl.IMAGE_3D: "image3D", gl.IMAGE_CUBE: "imageCube",
+	gl.IMAGE_2D_ARRAY: "image2DArray", gl.IMAGE_BUFFER: "imageBuffer",
+	gl.INT_IMAGE_2D: "iimage2D", gl.UNSIGNED_INT_IMAGE_2D: "uimage2D",
+	gl.UNSIGNED_INT_ATOMIC_COUNTER: "atomic_uint",
+}
+
+func (t GLSLType) String() string {
+	if name, ok := glslTypeNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("GLSLType(0x%x)", uint32(t))
+}
+
+// IsSampler reports whether the type is a texture sampler.
+func (t GLSLType) IsSampler() bool {
+	return strings.Contains(t.String(), "sampler")
+}
+
+// IsImage reports whether the type is an image unit binding.
+func (t GLSLType) IsImage() bool {
+	name := t.String()
+	return strings.HasPrefix(name, "image") || strings.HasPrefix(name[1:], "image")
+}
+
+type UniformInfo struct {
+	Name     string // arrays keep GL's "[0]" suffix
+	Type     GLSLType
+	Size     int32 // number of array elements, 1 for non arrays
+	Location int32 // -1 for members of uniform blocks
+	Block    int32 // index of the uniform block, -1 for default block uniforms
+}
+
+type AttributeInfo struct {
+	Name     string
+	Type     GLSLType
+	Size     int32
+	Location int32
+}
+
+// BlockInfo describes a uniform block or a shader storage block.
+type BlockInfo struct {
+	Name     string
+	Index    uint32
+	Binding  int32
+	DataSize int32 // minimum buffer size in bytes
+	Members  []string
+}
+
+// ShaderReflection lists what a linked program expects from the application.
+type ShaderReflection struct {
+	Uniforms      []UniformInfo
+	Attributes    []AttributeInfo
+	UniformBlocks []BlockInfo
+	StorageBlocks []BlockInfo
+}
+
+var programReflections = map[Shader]*ShaderReflection{}
+
+// Reflect returns the active uniforms, attributes and blocks of the program.
+// The result is gathered once, when the program is linked, and must not be modified.
+func (sh *Shader) Reflect() *ShaderReflection {
+	r, ok := programReflections[*sh]
+	if !ok {
+		r = reflectProgram(uint32(*sh))
+		programReflections[*sh] = r
+	}
+	return r
+}
+
+// Uniform returns the active uniform with that name. Array uniforms can be
+// looked up with or without their "[0]" suffix.
+func (r *ShaderReflection) Uniform(name string) (UniformInfo, bool) {
+	for _, u := range r.Uniforms {
+		if u.Name == name || strings.TrimSuffix(u.Name, "[0]") == name {
+			return u, true
+		}
+	}
+	return UniformInfo{}, false
+}
+
+// Samplers returns the names of the sampler uniforms of the default block.
+func (r *ShaderReflection) Samplers() []string {
+	var names []string
+	for _, u := range r.Uniforms {
+		if u.Type.IsSampler() {
+			names = append(names, strings.TrimSuffix(u.Name, "[0]"))
+		}
+	}
+	return names
+}
+
+func reflectProgram(program uint32) *ShaderReflection {
+	r := &ShaderReflection{}
+
+	for i, name := range resourceNames(program, gl.UNIFORM) {
+		props := resourceProperties(program, gl.UNIFORM, uint32(i), gl.TYPE, gl.ARRAY_SIZE, gl.LOCATION, gl.BLOCK_INDEX)
+		r.Uniforms = append(r.Uniforms, UniformInfo{
+			Name:     name,
+			Type:     GLSLType(props[0]),
+			Size:     props[1],
+			Location: props[2],
+			Block:    props[3],
+		})
+	}
+
+	for i, name := range resourceNames(program, gl.PROGRAM_INPUT) {
+		props := resourceProperties(program, gl.PROGRAM_INPUT, uint32(i), gl.TYPE, gl.ARRAY_SIZE, gl.LOCATION)
+		r.Attributes = append(r.Attributes, AttributeInfo{
+			Name:     name,
+			Type:     GLSLType(props[0]),
+			Size:     props[1],
+			Location: props[2],
+		})
+	}
+
+	r.UniformBlocks = reflectBlocks(program, gl.UNIFORM_BLOCK, gl.UNIFORM)
+	r.StorageBlocks = reflectBlocks(program, gl.SHADER_STORAGE_BLOCK, gl.BUFFER_VARIABLE)
+
+	return r
+}
+
+func reflectBlocks(program, blockInterface, memberInterface uint32) []BlockInfo {
+	var blocks []BlockInfo
+	for i, name := range resourceNames(program, blockInterface) {
+		props := resourceProperties(program, blockInterface, uint32(i), gl.BUFFER_BINDING, gl.BUFFER_DATA_SIZE, gl.NUM_ACTIVE_VARIABLES)
+		block := BlockInfo{Name: name, Index: uint32(i), Binding: props[0], DataSize: props[1]}
+
+		if count := props[2]; count > 0 {
+			members := make([]int32, count)
+			prop := uint32(gl.ACTIVE_VARIABLES)
+			gl.GetProgramResourceiv(program, blockInterface, uint32(i), 1, &prop, count, nil, &members[0])
+			for _, member := range members {
+				block.Members = append(block.Members, resourceName(program, memberInterface, uint32(member)))
+			}
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks
+}
+
+func resourceNames(program, programInterface uint32) []string {
+	var count int32
+	gl.GetProgramInterfaceiv(program, programInterface, gl.ACTIVE_RESOURCES, &count)
+
+	names := make([]string, count)
+	for i := range names {
+		names[i] = resourceName(program, programInterface, uint32(i))
+	}
+	return names
+}
+
+func resourceName(program, programInterface, index uint32) string {
+	var maxLength int32
+	gl.GetProgramInterfaceiv(program, programInterface, gl.MAX_NAME_LENGTH, &maxLength)
+	if maxLength == 0 {
+		return ""
+	}
+
+	var length int32
+	buf := make([]uint8, maxLength)
+	gl.GetProgramResourceName(program, programInterface, index, maxLength, &length, &buf[0])
+	return string(buf[:length])
+}
+
+func resourceProperties(program, programInterface, index uint32, props ...uint32) []int32 {
+	values := make([]int32, len(props))
+	gl.GetProgramResourceiv(program, programInterface, index, int32(len(props)), &props[0], int32(len(values)), nil, &values[0])
+	return values
+}
+
+// hasSampler reports whether the shader has a sampler uniform called name.
+func (sh *Shader) hasSampler(name string) bool {
+	u, ok := sh.Reflect().Uniform(name)
+	return ok && u.Type.IsSampler()
+}
+
+// warnUnboundTexture warns, once per program and name, about a texture left
+// unbound because its name does not match any sampler of the shader.
+func (sh *Shader) warnUnboundTexture(name string) {
+	sh.uniforms().warnOnce("texture:"+name, "Texture %s does not match any sampler of shader %d and is not bound, samplers are %v\n", name, *sh, sh.Reflect().Samplers())
+}
@@ -0,0 +1,269 @@
+package noor
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/ahmedsat/madar"
+	"github.com/go-gl/gl/v4.6-core/gl"
+)
+
+// DrawItem is an object with a mesh, queued for drawing.
+type DrawItem struct {
+	Object   *Object
+	Shader   Shader
+	Material *Material
+	Mesh     *Mesh
+	World    [16]float32
+
+	// Distance is the squared distance from the camera to the object's origin.
+	Distance float32
+
+	materialID, stateID, meshID int
+}
+
+// Transparent reports whether the item is blended and must be drawn after the
+// opaque items, back to front.
+func (item *DrawItem) Transparent() bool {
+	return item.Object.RenderState().Transparent()
+}
+
+// batchable reports whether the item can share an instanced draw call with
+// items using the same shader, material and mesh. Objects overriding the
+// material's render state are drawn on their own, and so is everything drawn
+// with a shader that does not read instances through uInstanced.
+func (item *DrawItem) batchable() bool {
+	return len(item.Object.Textures) == 0 && len(item.Object.Instances) == 0 && item.Object.State == nil &&
+		item.Shader.HasUniform("uInstanced")
+}
+
+// RenderStats counts the work of a frame.
+type RenderStats struct {
+	DrawCalls        int
+	Objects          int // objects drawn, instanced ones included
+	ShaderSwitches   int
+	MaterialSwitches int
+	MeshSwitches     int
+	TextureBinds     int
+	StateChanges     int // GL render state calls, see RenderState
+}
+
+// RenderQueue collects the objects of a scene, sorts them to minimize state
+// changes and draws them. Opaque items are sorted by shader, material, state, mesh
+// and then front to back, transparent items back to front.
+type RenderQueue struct {
+	Opaque      []DrawItem
+	Transparent []DrawItem
+
+	// Instancing draws runs of opaque items sharing a shader, material and mesh
+	// with one instanced draw call.
+	Instancing bool
+
+	Stats RenderStats
+
+	// state of the last draw, to skip redundant changes
+	shader    Shader
+	material  *Material
+	bound     bool // shader and material are valid
+	mesh      *Mesh
+	unit      uint32
+	cameraSet map[Shader]bool
+
+	// the last item bound textures of its own, one of which may have taken
+	// uTexture from the empty unit the material or shader reset set it to
+	ownTextures bool
+
+	materialIDs map[*Material]int
+	stateIDs    map[RenderState]int
+	meshIDs     map[*Mesh]int
+}
+
+func NewRenderQueue() *RenderQueue {
+	return &RenderQueue{
+		cameraSet:   map[Shader]bool{},
+		materialIDs: map[*Material]int{},
+		stateIDs:    map[RenderState]int{},
+		meshIDs:     map[*Mesh]int{},
+	}
+}
+
+// Reset empties the queue and its statistics, keeping allocated memory.
+func (q *RenderQueue) Reset() {
+	q.Opaque = q.Opaque[:0]
+	q.Transparent = q.Transparent[:0]
+	q.Stats = RenderStats{}
+	q.bound = false
+	q.mesh = nil
+	clear(q.cameraSet)
+	clear(q.materialIDs)
+	clear(q.stateIDs)
+	clear(q.meshIDs)
+}
+
+// Add queues an object, sorted by its distance from eye, the camera position.
+// Objects without a mesh are ignored.
+func (q *RenderQueue) Add(obj *Object, eye madar.Vector3) {
+	if obj.Mesh == nil {
+		return
+	}
+
+	item := DrawItem{
+		Object:   obj,
+		Shader:   obj.ActiveShader(),
+		Material: obj.Material,
+		Mesh:     obj.Mesh,
+		World:    obj.WorldMatrix(),
+	}
+
+	d := sub(madar.Vector3{X: item.World[12], Y: item.World[13], Z: item.World[14]}, eye)
+	item.Distance = dot(d, d)
+
+	item.materialID = assignID(q.materialIDs, item.Material)
+	item.stateID = assignID(q.stateIDs, obj.RenderState())
+	item.meshID = assignID(q.meshIDs, item.Mesh)
+
+	if item.Transparent() {
+		q.Transparent = append(q.Transparent, item)
+	} else {
+		q.Opaque = append(q.Opaque, item)
+	}
+}
+
+// AddScene queues every object of the scene.
+func (q *RenderQueue) AddScene(s *Scene) {
+	eye := cameraPosition(s.Camera)
+	s.Traverse(func(obj *Object) bool {
+		q.Add(obj, eye)
+		return true
+	})
+}
+
+func assignID[K comparable](ids map[K]int, key K) int {
+	id, ok := ids[key]
+	if !ok {
+		id = len(ids)
+		ids[key] = id
+	}
+	return id
+}
+
+func (q *RenderQueue) Sort() {
+	slices.SortStableFunc(q.Opaque, func(a, b DrawItem) int {
+		return cmp.Or(
+			cmp.Compare(a.Shader, b.Shader),
+			cmp.Compare(a.materialID, b.materialID),
+			cmp.Compare(a.stateID, b.stateID),
+			cmp.Compare(a.meshID, b.meshID),
+			cmp.Compare(a.Distance, b.Distance),
+		)
+	})
+	slices.SortStableFunc(q.Transparent, func(a, b DrawItem) int {
+		return cmp.Compare(b.Distance, a.Distance)
+	})
+}
+
+// Flush draws the opaque items and then the transparent ones.
+func (q *RenderQueue) Flush(camera Camera) {
+	changes := RenderStateChanges()
+	defer func() { q.Stats.StateChanges += RenderStateChanges() - changes }()
+
+	q.flush(q.Opaque, camera, q.Instancing)
+	q.flush(q.Transparent, camera, false)
+	gl.BindVertexArray(0)
+	q.mesh = nil
+}
+
+func (q *RenderQueue) flush(items []DrawItem, camera Camera, instancing bool) {
+	for i := 0; i < len(items); {
+		item := &items[i]
+
+		end := i + 1
+		if instancing && item.batchable() {
+			for end < len(items) && items[end].batchable() &&
+				items[end].Shader == item.Shader && items[end].Material == item.Material && items[end].Mesh == item.Mesh {
+				end++
+			}
+		}
+
+		sh := q.bind(item, camera)
+		switch {
+		case end-i > 1:
+			instances := make([]InstanceData, 0, end-i)
+			for _, batched := range items[i:end] {
+				instances = append(instances, NewInstance(batched.World))
+			}
+			sh.SetUniformMat4("uModel", identity())
+			q.drawInstanced(sh, item.Mesh, instances)
+
+		case len(item.Object.Instances) > 0:
+			sh.SetUniformMat4("uModel", item.World)
+			q.drawInstanced(sh, item.Mesh, item.Object.Instances)
+
+		default:
+			sh.SetUniformMat4("uModel", item.World)
+			setInstanced(sh, false)
+			q.bindMesh(item.Mesh)
+			item.Mesh.drawCall()
+			q.Stats.DrawCalls++
+			q.Stats.Objects++
+		}
+
+		i = end
+	}
+}
+
+// bind switches to the item's shader and material when they differ from the
+// previous item's, and binds its textures.
+func (q *RenderQueue) bind(item *DrawItem, camera Camera) Shader {
+	sh := item.Shader
+
+	if !q.bound || sh != q.shader || item.Material != q.material || q.ownTextures {
+		if !q.bound || sh != q.shader {
+			q.Stats.ShaderSwitches++
+		}
+		if item.Material != nil {
+			q.unit = item.Material.Apply()
+			q.Stats.MaterialSwitches++
+			q.Stats.TextureBinds += int(q.unit)
+		} else {
+			sh.Activate()
+			q.unit = resetMaterialUniforms(sh)
+		}
+		q.shader, q.material, q.bound = sh, item.Material, true
+	}
+
+	// cheap when unchanged, the state cache skips redundant GL calls
+	item.Object.RenderState().apply()
+
+	if !q.cameraSet[sh] {
+		sh.SetUniformMatrixFloat32("uView", camera.View())
+		sh.SetUniformMatrixFloat32("uProjection", camera.Projection())
+		q.cameraSet[sh] = true
+	}
+
+	unit := bindTextures(sh, item.Object.Textures, q.unit)
+	q.Stats.TextureBinds += int(unit - q.unit)
+	q.ownTextures = unit > q.unit
+
+	return sh
+}
+
+func (q *RenderQueue) bindMesh(mesh *Mesh) {
+	if mesh != q.mesh {
+		gl.BindVertexArray(mesh.VAO)
+		q.mesh = mesh
+		q.Stats.MeshSwitches++
+	}
+}
+
+func (q *RenderQueue) drawInstanced(sh Shader, mesh *Mesh, instances []InstanceData) {
+	// SetInstances rebinds the vertex array
+	SetInstances(mesh, InstanceLayout, instances)
+	q.mesh = nil
+
+	setInstanced(sh, true)
+	q.bindMesh(mesh)
+	mesh.drawInstancedCall(int32(len(instances)))
+	q.Stats.DrawCalls++
+	q.Stats.Objects += len(instances)
+}
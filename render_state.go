@@ -0,0 +1,294 @@
+package noor
+
+import (
+	"github.com/go-gl/gl/v4.6-core/gl"
+)
+
+type BlendMode int
+
+const (
+	BlendNone          BlendMode = iota
+	BlendAlpha                   // straight alpha: src*a + dst*(1-a)
+	BlendAdditive                // src*a + dst
+	BlendPremultiplied           // src + dst*(1-a), for colors already multiplied by alpha
+	BlendMultiply                // src * dst
+)
+
+type CullMode int
+
+const (
+	CullNone CullMode = iota
+	CullBack
+	CullFront
+	CullFrontAndBack
+)
+
+type Winding uint32
+
+const (
+	WindingCCW Winding = gl.CCW
+	WindingCW  Winding = gl.CW
+)
+
+// CompareFunc is the test of depth and stencil comparisons.
+type CompareFunc uint32
+
+const (
+	CompareNever        CompareFunc = gl.NEVER
+	CompareLess         CompareFunc = gl.LESS
+	CompareEqual        CompareFunc = gl.EQUAL
+	CompareLessEqual    CompareFunc = gl.LEQUAL
+	CompareGreater      CompareFunc = gl.GREATER
+	CompareNotEqual     CompareFunc = gl.NOTEQUAL
+	CompareGreaterEqual CompareFunc = gl.GEQUAL
+	CompareAlways       CompareFunc = gl.ALWAYS
+)
+
+type StencilOp uint32
+
+const (
+	StencilKeep          StencilOp = gl.KEEP
+	StencilZero          StencilOp = gl.ZERO
+	StencilReplace       StencilOp = gl.REPLACE
+	StencilIncrement     StencilOp = gl.INCR
+	StencilIncrementWrap StencilOp = gl.INCR_WRAP
+	StencilDecrement     StencilOp = gl.DECR
+	StencilDecrementWrap StencilOp = gl.DECR_WRAP
+	StencilInvert        StencilOp = gl.INVERT
+)
+
+type PolygonMode uint32
+
+const (
+	PolygonFill  PolygonMode = gl.FILL
+	PolygonLine  PolygonMode = gl.LINE // wireframe
+	PolygonPoint PolygonMode = gl.POINT
+)
+
+// ColorChannels is a set of color channels.
+type ColorChannels uint8
+
+const (
+	ChannelRed ColorChannels = 1 << iota
+	ChannelGreen
+	ChannelBlue
+	ChannelAlpha
+
+	ChannelsRGB = ChannelRed | ChannelGreen | ChannelBlue
+	ChannelsAll = ChannelsRGB | ChannelAlpha
+)
+
+type StencilState struct {
+	Enabled bool
+	Func    CompareFunc
+	Ref     int32
+
+	// A zero mask means 0xff, all bits of an 8 bit stencil buffer. Leave the
+	// ops at StencilKeep to not write the stencil.
+	ReadMask  uint32
+	WriteMask uint32
+
+	Fail      StencilOp // stencil test failed
+	DepthFail StencilOp // stencil test passed, depth test failed
+	Pass      StencilOp // both passed
+}
+
+// PolygonOffset pushes depth values away, Factor scaled by the slope of the
+// polygon and Units by the smallest depth step. The zero value disables it.
+type PolygonOffset struct {
+	Factor float32
+	Units  float32
+}
+
+// RenderState is the fixed function state a material or object is drawn with.
+// The zero value draws opaque with depth testing and writing, like
+// DefaultRenderState: zero enums fall back to CompareLess for DepthFunc,
+// WindingCCW, CompareAlways, 0xff masks and StencilKeep for the stencil,
+// PolygonFill.
+type RenderState struct {
+	Blend     BlendMode
+	Cull      CullMode
+	FrontFace Winding
+
+	NoDepthTest  bool // draw over everything, e.g. for overlays
+	NoDepthWrite bool // test against depth without writing it, e.g. for transparent surfaces
+	DepthFunc    CompareFunc
+
+	Stencil StencilState
+
+	PolygonMode   PolygonMode
+	PolygonOffset PolygonOffset
+
+	// MaskedChannels are not written to the color buffer, e.g. ChannelsAll
+	// for a depth only pass. The zero value writes every channel.
+	MaskedChannels ColorChannels
+}
+
+func DefaultRenderState() RenderState {
+	return RenderState{
+		FrontFace: WindingCCW,
+		DepthFunc: CompareLess,
+		Stencil: StencilState{
+			Func:      CompareAlways,
+			ReadMask:  0xff,
+			WriteMask: 0xff,
+			Fail:      StencilKeep,
+			DepthFail: StencilKeep,
+			Pass:      StencilKeep,
+		},
+		PolygonMode: PolygonFill,
+	}
+}
+
+// Transparent reports whether the state blends, so it has to be drawn after
+// opaque geometry.
+func (rs RenderState) Transparent() bool {
+	return rs.Blend != BlendNone
+}
+
+// resolved replaces zero enums with their defaults.
+func (rs RenderState) resolved() RenderState {
+	if rs.FrontFace == 0 {
+		rs.FrontFace = WindingCCW
+	}
+	if rs.DepthFunc == 0 {
+		rs.DepthFunc = CompareLess
+	}
+	if rs.Stencil.Func == 0 {
+		rs.Stencil.Func = CompareAlways
+	}
+	for _, mask := range []*uint32{&rs.Stencil.ReadMask, &rs.Stencil.WriteMask} {
+		if *mask == 0 {
+			*mask = 0xff
+		}
+	}
+	for _, op := range []*StencilOp{&rs.Stencil.Fail, &rs.Stencil.DepthFail, &rs.Stencil.Pass} {
+		if *op == 0 {
+			*op = StencilKeep
+		}
+	}
+	if rs.PolygonMode == 0 {
+		rs.PolygonMode = PolygonFill
+	}
+	return rs
+}
+
+// stateCache mirrors the GL state last set by apply, so unchanged state is not
+// sent to the driver again.
+var stateCache struct {
+	valid   bool
+	current RenderState
+	changes int
+}
+
+// InvalidateRenderState forgets the cached GL state. Call it after changing
+// blending, culling, depth, stencil or polygon state with raw GL calls.
+func InvalidateRenderState() {
+	stateCache.valid = false
+}
+
+// RenderStateChanges returns the number of GL state calls made by render
+// states since the program started.
+func RenderStateChanges() int {
+	return stateCache.changes
+}
+
+func (rs RenderState) apply() {
+	rs = rs.resolved()
+	cur := &stateCache.current
+	force := !stateCache.valid
+	changed := func(differs bool) bool {
+		if force || differs {
+			stateCache.changes++
+			return true
+		}
+		return false
+	}
+
+	if changed(rs.Blend != cur.Blend) {
+		setCapability(gl.BLEND, rs.Blend != BlendNone)
+		switch rs.Blend {
+		case BlendAlpha:
+			gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
+		case BlendAdditive:
+			gl.BlendFunc(gl.SRC_ALPHA, gl.ONE)
+		case BlendPremultiplied:
+			gl.BlendFunc(gl.ONE, gl.ONE_MINUS_SRC_ALPHA)
+		case BlendMultiply:
+			gl.BlendFunc(gl.DST_COLOR, gl.ZERO)
+		}
+	}
+
+	if changed(rs.Cull != cur.Cull) {
+		setCapability(gl.CULL_FACE, rs.Cull != CullNone)
+		switch rs.Cull {
+		case CullBack:
+			gl.CullFace(gl.BACK)
+		case CullFront:
+			gl.CullFace(gl.FRONT)
+		case CullFrontAndBack:
+			gl.CullFace(gl.FRONT_AND_BACK)
+		}
+	}
+	if changed(rs.FrontFace != cur.FrontFace) {
+		gl.FrontFace(uint32(rs.FrontFace))
+	}
+
+	if changed(rs.NoDepthTest != cur.NoDepthTest) {
+		setCapability(gl.DEPTH_TEST, !rs.NoDepthTest)
+	}
+	if changed(rs.NoDepthWrite != cur.NoDepthWrite) {
+		gl.DepthMask(!rs.NoDepthWrite)
+	}
+	if changed(rs.DepthFunc != cur.DepthFunc) {
+		gl.DepthFunc(uint32(rs.DepthFunc))
+	}
+
+	s, c := rs.Stencil, cur.Stencil
+	if changed(s.Enabled != c.Enabled) {
+		setCapability(gl.STENCIL_TEST, s.Enabled)
+	}
+	if changed(s.Func != c.Func || s.Ref != c.Ref || s.ReadMask != c.ReadMask) {
+		gl.StencilFunc(uint32(s.Func), s.Ref, s.ReadMask)
+	}
+	if changed(s.WriteMask != c.WriteMask) {
+		gl.StencilMask(s.WriteMask)
+	}
+	if changed(s.Fail != c.Fail || s.DepthFail != c.DepthFail || s.Pass != c.Pass) {
+		gl.StencilOp(uint32(s.Fail), uint32(s.DepthFail), uint32(s.Pass))
+	}
+
+	if changed(rs.PolygonMode != cur.PolygonMode) {
+		gl.PolygonMode(gl.FRONT_AND_BACK, uint32(rs.PolygonMode))
+	}
+	if changed(rs.PolygonOffset != cur.PolygonOffset) {
+		enabled := rs.PolygonOffset != PolygonOffset{}
+		setCapability(gl.POLYGON_OFFSET_FILL, enabled)
+		setCapability(gl.POLYGON_OFFSET_LINE, enabled)
+		setCapability(gl.POLYGON_OFFSET_POINT, enabled)
+		gl.PolygonOffset(rs.PolygonOffset.Factor, rs.PolygonOffset.Units)
+	}
+
+	if changed(rs.MaskedChannels != cur.MaskedChannels) {
+		m := rs.MaskedChannels
+		gl.ColorMask(m&ChannelRed == 0, m&ChannelGreen == 0, m&ChannelBlue == 0, m&ChannelAlpha == 0)
+	}
+
+	stateCache.current = rs
+	stateCache.valid = true
+}
+
+// clearBuffers clears color, depth and stencil. The write masks of the last
+// drawn state would otherwise keep some of them from being cleared.
+func clearBuffers() {
+	DefaultRenderState().apply()
+	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT | gl.STENCIL_BUFFER_BIT)
+}
+
+func setCapability(capability uint32, enabled bool) {
+	if enabled {
+		gl.Enable(capability)
+	} else {
+		gl.Disable(capability)
+	}
+}
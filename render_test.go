@@ -0,0 +1,93 @@
+package noor_test
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/ahmedsat/noor"
+	"github.com/ahmedsat/noor/noortest"
+)
+
+func TestMain(m *testing.M) {
+	noortest.Main(m)
+}
+
+// checker is a 2x2 texture whose channels are all nonzero, so the default
+// shader shows it instead of the vertex color.
+func checker() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	img.SetRGBA(0, 0, color.RGBA{R: 200, G: 40, B: 40, A: 255})
+	img.SetRGBA(1, 0, color.RGBA{R: 40, G: 200, B: 40, A: 255})
+	img.SetRGBA(0, 1, color.RGBA{R: 40, G: 40, B: 200, A: 255})
+	img.SetRGBA(1, 1, color.RGBA{R: 220, G: 220, B: 220, A: 255})
+	return img
+}
+
+// TestRenderTexturedQuad draws a quad covering the viewport with the default
+// shader, unlit, through the identity DefaultCamera. The texture is uploaded
+// top row first, so its first row lands at v = 0, the bottom of the image.
+func TestRenderTexturedQuad(t *testing.T) {
+	opts := noortest.DefaultOptions()
+	opts.Width, opts.Height = 64, 64
+
+	noortest.RenderGolden(t, "textured_quad", opts, func(n *noor.Noor) {
+		normal := [3]float32{0, 0, 1}
+		white := [3]float32{1, 1, 1}
+		quad := noor.NewMesh([]noor.Vertex{
+			noor.NewVertex([3]float32{-1, -1, 0}, white, [2]float32{0, 0}, normal),
+			noor.NewVertex([3]float32{1, -1, 0}, white, [2]float32{1, 0}, normal),
+			noor.NewVertex([3]float32{1, 1, 0}, white, [2]float32{1, 1}, normal),
+			noor.NewVertex([3]float32{-1, 1, 0}, white, [2]float32{0, 1}, normal),
+		}, []uint32{0, 1, 2, 0, 2, 3}, noor.DrawTriangles)
+
+		tex, err := noor.NewTexture(checker(), "uTexture", noor.TextureParameters{
+			WrappingS:    noor.ClampToEdge,
+			WrappingT:    noor.ClampToEdge,
+			FilteringMin: noor.Nearest,
+			FilteringMag: noor.Nearest,
+		})
+		if err != nil {
+			panic(err)
+		}
+
+		obj := noor.NewObject("quad", quad)
+		obj.AddTexture(&tex)
+		n.AddObject(obj)
+	})
+}
+
+// TestRenderNormalMapOnlyMaterial draws the quad with a material whose only
+// texture is a normal map. Unlit, the normal map has no effect and uTexture,
+// pointed at an empty unit, leaves the vertex color.
+func TestRenderNormalMapOnlyMaterial(t *testing.T) {
+	opts := noortest.DefaultOptions()
+	opts.Width, opts.Height = 64, 64
+
+	noortest.RenderGolden(t, "normal_map_only", opts, func(n *noor.Noor) {
+		normal := [3]float32{0, 0, 1}
+		orange := [3]float32{1, 0.4, 0.2}
+		quad := noor.NewMesh([]noor.Vertex{
+			noor.NewVertex([3]float32{-1, -1, 0}, orange, [2]float32{0, 0}, normal),
+			noor.NewVertex([3]float32{1, -1, 0}, orange, [2]float32{1, 0}, normal),
+			noor.NewVertex([3]float32{1, 1, 0}, orange, [2]float32{1, 1}, normal),
+			noor.NewVertex([3]float32{-1, 1, 0}, orange, [2]float32{0, 1}, normal),
+		}, []uint32{0, 1, 2, 0, 2, 3}, noor.DrawTriangles)
+
+		// a flat normal map, which would tint the quad blue if it were sampled
+		// as uTexture
+		flat := image.NewRGBA(image.Rect(0, 0, 1, 1))
+		flat.SetRGBA(0, 0, color.RGBA{R: 128, G: 128, B: 255, A: 255})
+		normalMap, err := noor.NewTexture(flat, string(noor.SlotNormal), noor.DefaultTextureParameters())
+		if err != nil {
+			panic(err)
+		}
+
+		mat := noor.NewDefaultMaterial("normal mapped").UnwrapOrPanic()
+		mat.SetTexture(noor.SlotNormal, &normalMap)
+
+		obj := noor.NewObject("quad", quad)
+		obj.Material = mat
+		n.AddObject(obj)
+	})
+}
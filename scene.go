@@ -1,32 +1,111 @@
 package noor
 
+import (
+	"image/color"
+	"strings"
+)
+
 type Scene struct {
 	Objects []*Object
 	Camera  Camera
+
+	Lights  []*Light
+	Ambient color.Color
+
+	// Instancing groups objects sharing a mesh, material and shader into one
+	// instanced draw call. Objects with textures or instances of their own, and
+	// objects whose shader has no uInstanced uniform, are still drawn one by one.
+	Instancing bool
+
+	// Queue sorts and draws the objects every frame.
+	Queue *RenderQueue
 }
 
 func NewScene() *Scene {
 	return &Scene{
 		Objects: []*Object{},
 		Camera:  DefaultCamera{},
+		Lights:  []*Light{},
+		Ambient: color.RGBA{R: 0x1a, G: 0x1a, B: 0x1a, A: 0xff},
+		Queue:   NewRenderQueue(),
 	}
 }
 
+// AddObject adds obj as a root object of the scene, detaching it from any parent.
 func (s *Scene) AddObject(obj *Object) {
+	obj.Detach()
+	if obj.scene != nil {
+		obj.scene.removeRoot(obj)
+	}
 	s.Objects = append(s.Objects, obj)
+	obj.scene = s
 }
 
 func (s *Scene) RemoveObject(obj Object) {
 	for i, o := range s.Objects {
 		if o.Name == obj.Name {
 			s.Objects = append(s.Objects[:i], s.Objects[i+1:]...)
+			o.scene = nil
 			break
 		}
 	}
 }
 
-func (s *Scene) Render() {
+func (s *Scene) removeRoot(obj *Object) {
+	for i, o := range s.Objects {
+		if o == obj {
+			s.Objects = append(s.Objects[:i], s.Objects[i+1:]...)
+			break
+		}
+	}
+	obj.scene = nil
+}
+
+// Traverse walks every object in the scene depth first.
+// Returning false from fn skips the children of that object.
+func (s *Scene) Traverse(fn func(*Object) bool) {
 	for _, obj := range s.Objects {
-		obj.Render(s.Camera)
+		obj.Traverse(fn)
+	}
+}
+
+// Find looks up an object by a slash separated path of names, e.g. "tank/turret/barrel".
+// It returns nil if no such object exists.
+func (s *Scene) Find(path string) *Object {
+	return findObject(s.Objects, strings.Split(strings.Trim(path, "/"), "/"))
+}
+
+func (s *Scene) AddLight(light *Light) {
+	s.Lights = append(s.Lights, light)
+}
+
+func (s *Scene) RemoveLight(light *Light) {
+	for i, l := range s.Lights {
+		if l == light {
+			s.Lights = append(s.Lights[:i], s.Lights[i+1:]...)
+			break
+		}
+	}
+}
+
+func (s *Scene) Render() {
+	s.uploadLights()
+
+	if s.Queue == nil {
+		s.Queue = NewRenderQueue()
+	}
+	q := s.Queue
+	q.Reset()
+	q.Instancing = s.Instancing
+	q.AddScene(s)
+	q.Sort()
+	q.Flush(s.Camera)
+}
+
+// Stats returns the draw statistics of the last Render.
+func (s *Scene) Stats() RenderStats {
+	if s.Queue == nil {
+		return RenderStats{}
 	}
+	return s.Queue.Stats
 }
@@ -0,0 +1,52 @@
+package noor
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestReparentedRootsLeaveTheScene(t *testing.T) {
+	scene := &Scene{}
+	tank, turret, wheel := &Object{Name: "tank"}, &Object{Name: "turret"}, &Object{Name: "wheel"}
+	scene.AddObject(tank)
+	scene.AddObject(turret)
+	scene.AddObject(wheel)
+
+	tank.AddChild(turret)
+	wheel.SetParent(tank)
+	if !slices.Equal(scene.Objects, []*Object{tank}) {
+		t.Fatalf("roots are %v, want only the tank", names(scene.Objects))
+	}
+
+	// each object is visited once
+	var visited []string
+	scene.Traverse(func(o *Object) bool {
+		visited = append(visited, o.Name)
+		return true
+	})
+	if !slices.Equal(visited, []string{"tank", "turret", "wheel"}) {
+		t.Errorf("visited %v", visited)
+	}
+
+	// a detached child is not a root until it is added again
+	turret.Detach()
+	scene.AddObject(turret)
+	scene.AddObject(turret)
+	if !slices.Equal(scene.Objects, []*Object{tank, turret}) {
+		t.Errorf("roots are %v, want the tank and the turret once", names(scene.Objects))
+	}
+
+	other := &Scene{}
+	other.AddObject(turret)
+	if !slices.Equal(scene.Objects, []*Object{tank}) || !slices.Equal(other.Objects, []*Object{turret}) {
+		t.Errorf("moving the turret left roots %v and %v", names(scene.Objects), names(other.Objects))
+	}
+}
+
+func names(objects []*Object) []string {
+	var names []string
+	for _, o := range objects {
+		names = append(names, o.Name)
+	}
+	return names
+}
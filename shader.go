@@ -12,25 +12,121 @@ import (
 type Shader uint32
 
 func CreateShaderProgram(vertexShaderSource, fragmentShaderSource string) Result[Shader] {
+	return linkProgram(
+		shaderStage{kind: gl.VERTEX_SHADER, source: vertexShaderSource},
+		shaderStage{kind: gl.FRAGMENT_SHADER, source: fragmentShaderSource},
+	)
+}
+
+type shaderStage struct {
+	kind   uint32
+	source string
+	origin *ProcessedSource // maps compile errors back to the original files, nil for plain sources
+}
+
+var stageNames = map[uint32]string{
+	gl.VERTEX_SHADER:          "vertex",
+	gl.TESS_CONTROL_SHADER:    "tessellation control",
+	gl.TESS_EVALUATION_SHADER: "tessellation evaluation",
+	gl.GEOMETRY_SHADER:        "geometry",
+	gl.FRAGMENT_SHADER:        "fragment",
+	gl.COMPUTE_SHADER:         "compute",
+}
+
+// ShaderStages holds the sources of a program. Vertex and Fragment are
+// required, the other stages are optional and left out when empty.
+// A program with tessellation stages draws meshes with DrawMode gl.PATCHES,
+// see SetPatchVertices.
+type ShaderStages struct {
+	Vertex         string
+	TessControl    string
+	TessEvaluation string
+	Geometry       string
+	Fragment       string
+}
+
+func CreateShaderProgramWithStages(stages ShaderStages) Result[Shader] {
+	if stages.Vertex == "" || stages.Fragment == "" {
+		return Err[Shader](errors.New("a shader program needs a vertex and a fragment stage"))
+	}
+
+	var list []shaderStage
+	for _, stage := range []shaderStage{
+		{kind: gl.VERTEX_SHADER, source: stages.Vertex},
+		{kind: gl.TESS_CONTROL_SHADER, source: stages.TessControl},
+		{kind: gl.TESS_EVALUATION_SHADER, source: stages.TessEvaluation},
+		{kind: gl.GEOMETRY_SHADER, source: stages.Geometry},
+		{kind: gl.FRAGMENT_SHADER, source: stages.Fragment},
+	} {
+		if stage.source != "" {
+			list = append(list, stage)
+		}
+	}
+	return linkProgram(list...)
+}
+
+// SetPatchVertices sets the number of vertices per patch for tessellation.
+func SetPatchVertices(count int32) {
+	gl.PatchParameteri(gl.PATCH_VERTICES, count)
+}
+
+func linkProgram(stages ...shaderStage) Result[Shader] {
 
 	sh := Shader(gl.CreateProgram())
 
-	if err := compileShaderAndAttach(uint32(sh), vertexShaderSource, gl.VERTEX_SHADER); err != nil {
-		return Err[Shader](errors.Join(err, errors.New("failed to compile vertex shader")))
+	cachePath := ""
+	if programCacheEnabled() {
+		cachePath = programCachePath(stages)
+		if loadProgramBinary(uint32(sh), cachePath) {
+			cacheUniformLocations(sh)
+			return Ok[Shader](sh)
+		}
+		gl.ProgramParameteri(uint32(sh), gl.PROGRAM_BINARY_RETRIEVABLE_HINT, gl.TRUE)
 	}
 
-	if err := compileShaderAndAttach(uint32(sh), fragmentShaderSource, gl.FRAGMENT_SHADER); err != nil {
-		return Err[Shader](errors.Join(err, errors.New("failed to compile fragment shader")))
+	for _, stage := range stages {
+		if err := compileShaderAndAttach(uint32(sh), stage); err != nil {
+			gl.DeleteProgram(uint32(sh))
+			return Err[Shader](errors.Join(err, fmt.Errorf("failed to compile %s shader", stageNames[stage.kind])))
+		}
 	}
 
 	gl.LinkProgram(uint32(sh))
 	if err := checkProgramLinkStatus(uint32(sh)); err != nil {
+		gl.DeleteProgram(uint32(sh))
 		return Err[Shader](errors.Join(err, errors.New("failed to link shader program")))
 	}
 
+	if cachePath != "" {
+		if err := storeProgramBinary(uint32(sh), cachePath); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning :Failed to cache shader program: %v\n", err)
+		}
+	}
+
+	cacheUniformLocations(sh)
+
 	return Ok[Shader](sh)
 }
 
+// sharedDefaultShader returns the shared program of the embedded default shaders,
+// sized for MaxLights lights. Compile errors point into the include files.
+func sharedDefaultShader() Result[Shader] {
+	defines := map[string]string{"MAX_LIGHTS": fmt.Sprint(MaxLights)}
+	vertex := expandDefaultShader("default.vert", defaultVertexSource, defines)
+	fragment := expandDefaultShader("default.frag", defaultFragmentSource, defines)
+	return sharedProgram(vertex.Code, fragment.Code, func() Result[Shader] {
+		return CreateShaderProgramFromSources(vertex, fragment)
+	})
+}
+
+// expandDefaultShader runs an embedded default shader through the Preprocessor,
+// which finds its <noor/...> includes in ShaderIncludes.
+func expandDefaultShader(name, source string, defines map[string]string) *ProcessedSource {
+	processed, err := NewPreprocessor(nil).Process(name, source, defines)
+	Assert(err == nil, fmt.Sprintf("failed to preprocess the default shader %s: %v", name, err))
+	return processed
+}
+
 func CreateShaderProgramFromFiles(vertexShaderPath, fragmentShaderPath string) Result[Shader] {
 
 	vertexShaderSourceResult := loadShaderSourceFromFile(vertexShaderPath)
@@ -53,20 +149,24 @@ func CreateShaderProgramFromFiles(vertexShaderPath, fragmentShaderPath string) R
 	vertexShaderSource := vertexShaderSourceResult.Ok
 	fragmentShaderSource := fragmentShaderSourceResult.Ok
 
-	return CreateShaderProgram(vertexShaderSource, fragmentShaderSource)
+	result := CreateShaderProgram(vertexShaderSource, fragmentShaderSource)
+	if result.IsOk() {
+		watchShader(result.Ok, vertexShaderPath, fragmentShaderPath)
+	}
+	return result
 }
 
-func compileShaderAndAttach(program uint32, source string, shaderType uint32) error {
-	shader := gl.CreateShader(shaderType)
+func compileShaderAndAttach(program uint32, stage shaderStage) error {
+	shader := gl.CreateShader(stage.kind)
 	defer gl.DeleteShader(shader)
 
-	cSources, free := gl.Strs(source + "\x00")
+	cSources, free := gl.Strs(stage.source + "\x00")
 	defer free()
 	gl.ShaderSource(shader, 1, cSources, nil)
 	gl.CompileShader(shader)
 
-	if err := checkShaderCompileStatus(shader); err != nil {
-		return fmt.Errorf("failed to compile shader (type: %d): %w", shaderType, err)
+	if err := checkShaderCompileStatus(shader, stage.origin); err != nil {
+		return fmt.Errorf("failed to compile shader (type: %d): %w", stage.kind, err)
 	}
 
 	gl.AttachShader(program, shader)
@@ -74,7 +174,7 @@ func compileShaderAndAttach(program uint32, source string, shaderType uint32) er
 	return nil
 }
 
-func checkShaderCompileStatus(shader uint32) error {
+func checkShaderCompileStatus(shader uint32, origin *ProcessedSource) error {
 	var status int32
 	gl.GetShaderiv(shader, gl.COMPILE_STATUS, &status)
 	if status == gl.FALSE {
@@ -83,6 +183,9 @@ func checkShaderCompileStatus(shader uint32) error {
 
 		log := strings.Repeat("\x00", int(logLength+1))
 		gl.GetShaderInfoLog(shader, logLength, nil, gl.Str(log))
+		if origin != nil {
+			log = origin.MapLog(log)
+		}
 
 		return fmt.Errorf("shader compilation failed: %v", log)
 	}
@@ -113,48 +216,14 @@ func loadShaderSourceFromFile(filePath string) Result[string] {
 	return Ok(string(source))
 }
 
-func (sh *Shader) SetUniformFloat32(name string, value float32) {
-	location := sh.GetUniformLocation(name)
-	gl.Uniform1f(location, value)
-
-}
-
-func (sh *Shader) SetUniformBool(name string, value bool) {
-	location := sh.GetUniformLocation(name)
-	gl.Uniform1i(location, int32(boolToInt(value)))
-}
-
-func boolToInt(value bool) int {
-	if value {
-		return 1
-	}
-	return 0
-}
-
-func (sh *Shader) SetUniformInt32(name string, value int32) {
-	location := sh.GetUniformLocation(name)
-	gl.Uniform1i(location, value)
-}
-
-func (sh *Shader) SetUniformMatrixFloat32(name string, value *float32) {
-	location := sh.GetUniformLocation(name)
-	gl.UniformMatrix4fv(location, 1, false, value)
-}
-
-func (sh *Shader) GetUniformLocation(name string) int32 {
-	sh.Activate()
-	nameCStr := gl.Str(name + "\x00")
-	location := gl.GetUniformLocation(uint32(*sh), nameCStr)
-	if location == -1 {
-		fmt.Fprintf(os.Stderr, "Uniform location not found: %s\n", name)
-	}
-	return location
-}
-
 func (sh *Shader) Activate() {
 	gl.UseProgram(uint32(*sh))
 }
 
 func (sh *Shader) Delete() {
+	unwatchShader(*sh)
+	delete(uniformCaches, *sh)
+	delete(programReflections, *sh)
+	forgetShader(*sh)
 	gl.DeleteProgram(uint32(*sh))
 }
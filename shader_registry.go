@@ -0,0 +1,89 @@
+package noor
+
+import (
+	"crypto/sha256"
+	"fmt"
+)
+
+// shaderEntry tracks the users of a program. Programs start with no
+// references; objects and materials Retain the programs they hold and
+// Release them when they let go, the last Release deletes the program.
+type shaderEntry struct {
+	refs int
+	key  [sha256.Size]byte
+	// shared is set for programs registered by SharedShaderProgram
+	shared bool
+}
+
+var (
+	shaderEntries = map[Shader]*shaderEntry{}
+	sharedShaders = map[[sha256.Size]byte]Shader{}
+)
+
+// SharedShaderProgram returns the live program compiled from these sources,
+// compiling it only the first time. The returned program is not retained,
+// holders call Retain and Release.
+func SharedShaderProgram(vertexShaderSource, fragmentShaderSource string) Result[Shader] {
+	return sharedProgram(vertexShaderSource, fragmentShaderSource, func() Result[Shader] {
+		return CreateShaderProgram(vertexShaderSource, fragmentShaderSource)
+	})
+}
+
+// sharedProgram returns the live program of these sources, building it with
+// create the first time.
+func sharedProgram(vertexShaderSource, fragmentShaderSource string, create func() Result[Shader]) Result[Shader] {
+	key := sha256.Sum256([]byte(fmt.Sprintf("%d\n%s%s", len(vertexShaderSource), vertexShaderSource, fragmentShaderSource)))
+	if sh, ok := sharedShaders[key]; ok {
+		return Ok(sh)
+	}
+
+	result := create()
+	if result.IsErr() {
+		return result
+	}
+
+	sharedShaders[key] = result.Ok
+	shaderEntries[result.Ok] = &shaderEntry{key: key, shared: true}
+	return result
+}
+
+// Retain adds a user to the program.
+func (sh *Shader) Retain() {
+	if *sh == 0 {
+		return
+	}
+	entry, ok := shaderEntries[*sh]
+	if !ok {
+		entry = &shaderEntry{}
+		shaderEntries[*sh] = entry
+	}
+	entry.refs++
+}
+
+// Release removes a user from the program and deletes it once no user is left.
+// Programs that were never retained are deleted right away.
+func (sh *Shader) Release() {
+	if *sh == 0 {
+		return
+	}
+	if entry, ok := shaderEntries[*sh]; ok && entry.refs > 1 {
+		entry.refs--
+		return
+	}
+	sh.Delete()
+}
+
+// RefCount returns the number of users of the program.
+func (sh *Shader) RefCount() int {
+	if entry, ok := shaderEntries[*sh]; ok {
+		return entry.refs
+	}
+	return 0
+}
+
+func forgetShader(sh Shader) {
+	if entry, ok := shaderEntries[sh]; ok && entry.shared {
+		delete(sharedShaders, entry.key)
+	}
+	delete(shaderEntries, sh)
+}
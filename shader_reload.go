@@ -0,0 +1,131 @@
+package noor
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/go-gl/gl/v4.6-core/gl"
+)
+
+// watchedShader remembers the source files of a program created with
+// CreateShaderProgramFromFiles so it can be rebuilt when they change.
+type watchedShader struct {
+	vertexPath   string
+	fragmentPath string
+	modTimes     [2]time.Time
+}
+
+var watchedShaders = map[Shader]*watchedShader{}
+
+func watchShader(sh Shader, vertexPath, fragmentPath string) {
+	w := &watchedShader{vertexPath: vertexPath, fragmentPath: fragmentPath}
+	w.modTimes = w.stat()
+	watchedShaders[sh] = w
+}
+
+func unwatchShader(sh Shader) {
+	delete(watchedShaders, sh)
+}
+
+func (w *watchedShader) stat() [2]time.Time {
+	var times [2]time.Time
+	for i, path := range []string{w.vertexPath, w.fragmentPath} {
+		if info, err := os.Stat(path); err == nil {
+			times[i] = info.ModTime()
+		}
+	}
+	return times
+}
+
+// EnableShaderHotReload makes Loop check the files of every program created with
+// CreateShaderProgramFromFiles once per interval. Changed programs are relinked
+// in place, see ReloadShaderProgram, so every holder of the Shader sees the new
+// code. If the new source does not compile the error is printed and the
+// previous program is kept. An interval of zero disables hot reload.
+func (n *Noor) EnableShaderHotReload(interval time.Duration) {
+	n.shaderReloadInterval = interval
+}
+
+// reloadShaders is called by Loop on the render thread.
+func (n *Noor) reloadShaders() {
+	if n.shaderReloadInterval <= 0 || time.Since(n.lastShaderCheck) < n.shaderReloadInterval {
+		return
+	}
+	n.lastShaderCheck = time.Now()
+
+	for sh, w := range watchedShaders {
+		modTimes := w.stat()
+		if modTimes == w.modTimes {
+			continue
+		}
+		w.modTimes = modTimes
+
+		if err := ReloadShaderProgram(sh); err != nil {
+			fmt.Fprintf(os.Stderr, "Error :Failed to reload shader (%s, %s), keeping the previous program: %v\n", w.vertexPath, w.fragmentPath, err)
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "Reloaded shader (%s, %s)\n", w.vertexPath, w.fragmentPath)
+	}
+}
+
+// ReloadShaderProgram rebuilds a program created with CreateShaderProgramFromFiles
+// from the current content of its files. The program is relinked in place and
+// keeps its handle, so objects, materials, post effects and any other holder
+// use the new code without being updated. Uniform values are reset, as after
+// any link. On failure the program is left untouched.
+func ReloadShaderProgram(sh Shader) error {
+	w, ok := watchedShaders[sh]
+	if !ok {
+		return fmt.Errorf("shader %d was not created from files", sh)
+	}
+
+	vertexSource := loadShaderSourceFromFile(w.vertexPath)
+	if vertexSource.IsErr() {
+		return vertexSource.Err
+	}
+	fragmentSource := loadShaderSourceFromFile(w.fragmentPath)
+	if fragmentSource.IsErr() {
+		return fragmentSource.Err
+	}
+
+	return relinkProgram(sh,
+		shaderStage{kind: gl.VERTEX_SHADER, source: vertexSource.Ok},
+		shaderStage{kind: gl.FRAGMENT_SHADER, source: fragmentSource.Ok},
+	)
+}
+
+// relinkProgram replaces the stages of a linked program. The stages are first
+// linked into a scratch program, so a compile or link error leaves sh as it was.
+func relinkProgram(sh Shader, stages ...shaderStage) error {
+	scratch := linkProgram(stages...)
+	if scratch.IsErr() {
+		return scratch.Err
+	}
+	scratch.Ok.Delete()
+
+	program := uint32(sh)
+	var count int32
+	attached := make([]uint32, len(stageNames))
+	gl.GetAttachedShaders(program, int32(len(attached)), &count, &attached[0])
+	for _, shader := range attached[:count] {
+		gl.DetachShader(program, shader)
+	}
+
+	for _, stage := range stages {
+		if err := compileShaderAndAttach(program, stage); err != nil {
+			return errors.Join(err, fmt.Errorf("failed to compile %s shader", stageNames[stage.kind]))
+		}
+	}
+	gl.LinkProgram(program)
+	if err := checkProgramLinkStatus(program); err != nil {
+		return errors.Join(err, errors.New("failed to relink shader program"))
+	}
+
+	// locations and reflection belong to the previous link
+	delete(uniformCaches, sh)
+	delete(programReflections, sh)
+	cacheUniformLocations(sh)
+	return nil
+}
@@ -51,8 +51,43 @@ const (
 	FormatSRGBA8  TextureFormat = gl.SRGB8_ALPHA8
 	FormatRGBA16F TextureFormat = gl.RGBA16F
 	FormatRGBA32F TextureFormat = gl.RGBA32F
+	FormatRG16F   TextureFormat = gl.RG16F
+	FormatR32F    TextureFormat = gl.R32F
+
+	FormatDepth24         TextureFormat = gl.DEPTH_COMPONENT24
+	FormatDepth32F        TextureFormat = gl.DEPTH_COMPONENT32F
+	FormatDepth24Stencil8 TextureFormat = gl.DEPTH24_STENCIL8
 )
 
+// pixelFormat returns the format and type of client pixel data matching an
+// internal format, used to allocate textures without data.
+func (f TextureFormat) pixelFormat() (format, xtype uint32) {
+	switch f {
+	case FormatDepth24, FormatDepth32F:
+		return gl.DEPTH_COMPONENT, gl.FLOAT
+	case FormatDepth24Stencil8:
+		return gl.DEPTH_STENCIL, gl.UNSIGNED_INT_24_8
+	case FormatR8:
+		return gl.RED, gl.UNSIGNED_BYTE
+	case FormatR32F:
+		return gl.RED, gl.FLOAT
+	case FormatRG8:
+		return gl.RG, gl.UNSIGNED_BYTE
+	case FormatRG16F:
+		return gl.RG, gl.FLOAT
+	case FormatRGB8:
+		return gl.RGB, gl.UNSIGNED_BYTE
+	case FormatRGBA16F, FormatRGBA32F:
+		return gl.RGBA, gl.FLOAT
+	}
+	return gl.RGBA, gl.UNSIGNED_BYTE
+}
+
+// IsDepth reports whether the format holds depth, and maybe stencil, values.
+func (f TextureFormat) IsDepth() bool {
+	return f == FormatDepth24 || f == FormatDepth32F || f == FormatDepth24Stencil8
+}
+
 type TextureParameters struct {
 	WrappingS, WrappingT       TextureWrapping
 	BorderColor                color.Color
@@ -73,6 +108,10 @@ type Texture struct {
 	Height     int32
 	Depth      int32
 	Parameters TextureParameters
+
+	// BottomUp is set when row 0 of the texture is the bottom of the image,
+	// as for render targets and images uploaded with FlipImage.
+	BottomUp bool
 }
 
 // NewTextureFromFile creates a new texture from a file path
@@ -111,6 +150,7 @@ func NewTexture(img image.Image, name string, parameters TextureParameters) (tex
 		Width:      int32(rgba.Rect.Size().X),
 		Height:     int32(rgba.Rect.Size().Y),
 		Parameters: parameters,
+		BottomUp:   parameters.FlipImage,
 	}
 
 	if err := tex.createAndSetup(rgba); err != nil {
@@ -126,27 +166,7 @@ func (tex *Texture) createAndSetup(rgba *image.RGBA) error {
 	gl.BindTexture(uint32(tex.Type), tex.Handle)
 	defer gl.BindTexture(uint32(tex.Type), 0)
 
-	// Set texture parameters
-	gl.TexParameteri(uint32(tex.Type), gl.TEXTURE_WRAP_S, int32(tex.Parameters.WrappingS))
-	gl.TexParameteri(uint32(tex.Type), gl.TEXTURE_WRAP_T, int32(tex.Parameters.WrappingT))
-	gl.TexParameteri(uint32(tex.Type), gl.TEXTURE_MIN_FILTER, int32(tex.Parameters.FilteringMin))
-	gl.TexParameteri(uint32(tex.Type), gl.TEXTURE_MAG_FILTER, int32(tex.Parameters.FilteringMag))
-
-	// Set anisotropic filtering if supported
-	if tex.Parameters.AnisotropyLevel > 0 {
-		gl.TexParameterf(uint32(tex.Type), gl.TEXTURE_MAX_ANISOTROPY, tex.Parameters.AnisotropyLevel)
-	}
-
-	// Set border color if using ClampToBorder
-	if tex.Parameters.WrappingS == ClampToBorder || tex.Parameters.WrappingT == ClampToBorder {
-		var borderColor [4]float32
-		r, g, b, a := tex.Parameters.BorderColor.RGBA()
-		borderColor[0] = float32(r) / 0xffff
-		borderColor[1] = float32(g) / 0xffff
-		borderColor[2] = float32(b) / 0xffff
-		borderColor[3] = float32(a) / 0xffff
-		gl.TexParameterfv(uint32(tex.Type), gl.TEXTURE_BORDER_COLOR, &borderColor[0])
-	}
+	tex.applyParameters()
 
 	// Upload pixel data
 	gl.TexImage2D(
@@ -176,6 +196,54 @@ func (tex *Texture) createAndSetup(rgba *image.RGBA) error {
 	return nil
 }
 
+// NewEmptyTexture creates a texture without data, e.g. to render into or to
+// write from a compute shader. The format comes from parameters.Format.
+func NewEmptyTexture(width, height int32, name string, parameters TextureParameters) (*Texture, error) {
+	initializeTextureParameters(&parameters)
+	tex := &Texture{
+		Name:       name,
+		Type:       parameters.Type,
+		Format:     parameters.Format,
+		Parameters: parameters,
+	}
+
+	gl.GenTextures(1, &tex.Handle)
+	gl.BindTexture(uint32(tex.Type), tex.Handle)
+	tex.applyParameters()
+	gl.BindTexture(uint32(tex.Type), 0)
+
+	if err := tex.Resize(width, height); err != nil {
+		tex.Delete()
+		return nil, fmt.Errorf("failed to create empty texture %s: %w", name, err)
+	}
+	return tex, nil
+}
+
+// applyParameters sets the wrapping and filtering of the bound texture.
+func (tex *Texture) applyParameters() {
+
+	gl.TexParameteri(uint32(tex.Type), gl.TEXTURE_WRAP_S, int32(tex.Parameters.WrappingS))
+	gl.TexParameteri(uint32(tex.Type), gl.TEXTURE_WRAP_T, int32(tex.Parameters.WrappingT))
+	gl.TexParameteri(uint32(tex.Type), gl.TEXTURE_MIN_FILTER, int32(tex.Parameters.FilteringMin))
+	gl.TexParameteri(uint32(tex.Type), gl.TEXTURE_MAG_FILTER, int32(tex.Parameters.FilteringMag))
+
+	// Set anisotropic filtering if supported
+	if tex.Parameters.AnisotropyLevel > 0 {
+		gl.TexParameterf(uint32(tex.Type), gl.TEXTURE_MAX_ANISOTROPY, tex.Parameters.AnisotropyLevel)
+	}
+
+	// Set border color if using ClampToBorder
+	if tex.Parameters.WrappingS == ClampToBorder || tex.Parameters.WrappingT == ClampToBorder {
+		var borderColor [4]float32
+		r, g, b, a := tex.Parameters.BorderColor.RGBA()
+		borderColor[0] = float32(r) / 0xffff
+		borderColor[1] = float32(g) / 0xffff
+		borderColor[2] = float32(b) / 0xffff
+		borderColor[3] = float32(a) / 0xffff
+		gl.TexParameterfv(uint32(tex.Type), gl.TEXTURE_BORDER_COLOR, &borderColor[0])
+	}
+}
+
 // UpdateData updates the texture data for a region of the texture
 func (tex *Texture) UpdateData(xOffset, yOffset int32, width, height int32, data []byte) error {
 	gl.BindTexture(uint32(tex.Type), tex.Handle)
@@ -198,6 +266,7 @@ func (tex *Texture) UpdateData(xOffset, yOffset int32, width, height int32, data
 
 // Resize resizes the texture to the specified dimensions
 func (tex *Texture) Resize(width, height int32) error {
+	format, xtype := tex.Format.pixelFormat()
 	gl.BindTexture(uint32(tex.Type), tex.Handle)
 	defer gl.BindTexture(uint32(tex.Type), 0)
 
@@ -208,8 +277,8 @@ func (tex *Texture) Resize(width, height int32) error {
 		width,
 		height,
 		0,
-		gl.RGBA,
-		gl.UNSIGNED_BYTE,
+		format,
+		xtype,
 		nil,
 	)
 
@@ -232,6 +301,14 @@ func (tex *Texture) Delete() {
 }
 
 // Activate binds the texture to a specific texture unit and sets it in the shader.
+// bindEmptyTexture unbinds the 2D texture of unit and points the sampler at it.
+// Sampling it gives (0, 0, 0, 1), which the default shader reads as no texture.
+func bindEmptyTexture(sh Shader, unit uint32, uniformName string) {
+	gl.ActiveTexture(gl.TEXTURE0 + unit)
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+	sh.SetUniformInt32(uniformName, int32(unit))
+}
+
 func (tex *Texture) Activate(sh Shader, unit uint32, uniformName string) error {
 	sh.Activate()
 	gl.ActiveTexture(gl.TEXTURE0 + unit)
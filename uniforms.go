@@ -0,0 +1,238 @@
+package noor
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unsafe"
+
+	"github.com/ahmedsat/madar"
+	"github.com/go-gl/gl/v4.6-core/gl"
+)
+
+// uniformCache holds the uniform locations of a program, so setting a uniform
+// does not go through glGetUniformLocation every frame.
+type uniformCache struct {
+	locations map[string]int32
+	warned    map[string]bool
+}
+
+var uniformCaches = map[Shader]*uniformCache{}
+
+// cacheUniformLocations reflects a linked program and caches the locations of
+// its uniforms. Arrays are stored under their base name and under every element name.
+func cacheUniformLocations(sh Shader) {
+	cache := &uniformCache{locations: map[string]int32{}, warned: map[string]bool{}}
+	program := uint32(sh)
+
+	for _, u := range sh.Reflect().Uniforms {
+		if u.Location == -1 {
+			// members of uniform blocks have no location
+			continue
+		}
+		cache.locations[u.Name] = u.Location
+
+		base, isArray := strings.CutSuffix(u.Name, "[0]")
+		if !isArray {
+			continue
+		}
+		cache.locations[base] = u.Location
+		for j := int32(1); j < u.Size; j++ {
+			element := fmt.Sprintf("%s[%d]", base, j)
+			cache.locations[element] = gl.GetUniformLocation(program, gl.Str(element+"\x00"))
+		}
+	}
+
+	uniformCaches[sh] = cache
+}
+
+func (c *uniformCache) warnOnce(key, format string, args ...any) {
+	if c.warned[key] {
+		return
+	}
+	c.warned[key] = true
+	fmt.Fprintf(os.Stderr, format, args...)
+}
+
+func (sh *Shader) uniforms() *uniformCache {
+	cache, ok := uniformCaches[*sh]
+	if !ok {
+		// programs not created through CreateShaderProgram
+		cacheUniformLocations(*sh)
+		cache = uniformCaches[*sh]
+	}
+	return cache
+}
+
+func (sh *Shader) lookupUniform(name string) int32 {
+	cache := sh.uniforms()
+	location, ok := cache.locations[name]
+	if !ok {
+		location = gl.GetUniformLocation(uint32(*sh), gl.Str(name+"\x00"))
+		cache.locations[name] = location
+	}
+	return location
+}
+
+// GetUniformLocation returns the cached location of a uniform. A missing uniform
+// is reported once per program and yields -1, which GL ignores.
+func (sh *Shader) GetUniformLocation(name string) int32 {
+	location := sh.lookupUniform(name)
+	if location == -1 {
+		sh.uniforms().warnOnce(name, "Uniform location not found: %s\n", name)
+	}
+	return location
+}
+
+// HasUniform reports whether the program has an active uniform with that name,
+// without warning when it does not.
+func (sh *Shader) HasUniform(name string) bool {
+	return sh.lookupUniform(name) != -1
+}
+
+// The setters below use glProgramUniform, so the program does not need to be active.
+
+func (sh *Shader) SetUniformFloat32(name string, value float32) {
+	gl.ProgramUniform1f(uint32(*sh), sh.GetUniformLocation(name), value)
+}
+
+func (sh *Shader) SetUniformBool(name string, value bool) {
+	gl.ProgramUniform1i(uint32(*sh), sh.GetUniformLocation(name), int32(boolToInt(value)))
+}
+
+func boolToInt(value bool) int {
+	if value {
+		return 1
+	}
+	return 0
+}
+
+func (sh *Shader) SetUniformInt32(name string, value int32) {
+	gl.ProgramUniform1i(uint32(*sh), sh.GetUniformLocation(name), value)
+}
+
+func (sh *Shader) SetUniformUInt32(name string, value uint32) {
+	gl.ProgramUniform1ui(uint32(*sh), sh.GetUniformLocation(name), value)
+}
+
+func (sh *Shader) SetUniformVec2(name string, x, y float32) {
+	gl.ProgramUniform2f(uint32(*sh), sh.GetUniformLocation(name), x, y)
+}
+
+func (sh *Shader) SetUniformVec3(name string, x, y, z float32) {
+	gl.ProgramUniform3f(uint32(*sh), sh.GetUniformLocation(name), x, y, z)
+}
+
+func (sh *Shader) SetUniformVec4(name string, x, y, z, w float32) {
+	gl.ProgramUniform4f(uint32(*sh), sh.GetUniformLocation(name), x, y, z, w)
+}
+
+func (sh *Shader) SetUniformIVec2(name string, x, y int32) {
+	gl.ProgramUniform2i(uint32(*sh), sh.GetUniformLocation(name), x, y)
+}
+
+func (sh *Shader) SetUniformIVec3(name string, x, y, z int32) {
+	gl.ProgramUniform3i(uint32(*sh), sh.GetUniformLocation(name), x, y, z)
+}
+
+func (sh *Shader) SetUniformIVec4(name string, x, y, z, w int32) {
+	gl.ProgramUniform4i(uint32(*sh), sh.GetUniformLocation(name), x, y, z, w)
+}
+
+func (sh *Shader) SetUniformUVec2(name string, x, y uint32) {
+	gl.ProgramUniform2ui(uint32(*sh), sh.GetUniformLocation(name), x, y)
+}
+
+func (sh *Shader) SetUniformUVec3(name string, x, y, z uint32) {
+	gl.ProgramUniform3ui(uint32(*sh), sh.GetUniformLocation(name), x, y, z)
+}
+
+func (sh *Shader) SetUniformUVec4(name string, x, y, z, w uint32) {
+	gl.ProgramUniform4ui(uint32(*sh), sh.GetUniformLocation(name), x, y, z, w)
+}
+
+// Matrices are column-major.
+
+func (sh *Shader) SetUniformMat2(name string, value [4]float32) {
+	gl.ProgramUniformMatrix2fv(uint32(*sh), sh.GetUniformLocation(name), 1, false, &value[0])
+}
+
+func (sh *Shader) SetUniformMat3(name string, value [9]float32) {
+	gl.ProgramUniformMatrix3fv(uint32(*sh), sh.GetUniformLocation(name), 1, false, &value[0])
+}
+
+func (sh *Shader) SetUniformMat4(name string, value [16]float32) {
+	gl.ProgramUniformMatrix4fv(uint32(*sh), sh.GetUniformLocation(name), 1, false, &value[0])
+}
+
+func (sh *Shader) SetUniformMatrixFloat32(name string, value *float32) {
+	gl.ProgramUniformMatrix4fv(uint32(*sh), sh.GetUniformLocation(name), 1, false, value)
+}
+
+func (sh *Shader) SetUniformVector3(name string, value madar.Vector3) {
+	sh.SetUniformVec3(name, value.X, value.Y, value.Z)
+}
+
+func (sh *Shader) SetUniformMatrix(name string, value *madar.Matrix) {
+	sh.SetUniformMatrixFloat32(name, value.Ptr())
+}
+
+// Array setters start at the first element of the uniform array.
+// Empty slices are ignored.
+
+func (sh *Shader) SetUniformFloat32Array(name string, values []float32) {
+	if len(values) == 0 {
+		return
+	}
+	gl.ProgramUniform1fv(uint32(*sh), sh.GetUniformLocation(name), int32(len(values)), &values[0])
+}
+
+func (sh *Shader) SetUniformInt32Array(name string, values []int32) {
+	if len(values) == 0 {
+		return
+	}
+	gl.ProgramUniform1iv(uint32(*sh), sh.GetUniformLocation(name), int32(len(values)), &values[0])
+}
+
+func (sh *Shader) SetUniformUInt32Array(name string, values []uint32) {
+	if len(values) == 0 {
+		return
+	}
+	gl.ProgramUniform1uiv(uint32(*sh), sh.GetUniformLocation(name), int32(len(values)), &values[0])
+}
+
+func (sh *Shader) SetUniformVec2Array(name string, values [][2]float32) {
+	if len(values) == 0 {
+		return
+	}
+	gl.ProgramUniform2fv(uint32(*sh), sh.GetUniformLocation(name), int32(len(values)), &values[0][0])
+}
+
+func (sh *Shader) SetUniformVec3Array(name string, values [][3]float32) {
+	if len(values) == 0 {
+		return
+	}
+	gl.ProgramUniform3fv(uint32(*sh), sh.GetUniformLocation(name), int32(len(values)), &values[0][0])
+}
+
+func (sh *Shader) SetUniformVec4Array(name string, values [][4]float32) {
+	if len(values) == 0 {
+		return
+	}
+	gl.ProgramUniform4fv(uint32(*sh), sh.GetUniformLocation(name), int32(len(values)), &values[0][0])
+}
+
+func (sh *Shader) SetUniformVector3Array(name string, values []madar.Vector3) {
+	if len(values) == 0 {
+		return
+	}
+	// madar.Vector3 is three packed float32, the same layout as a vec3 array
+	gl.ProgramUniform3fv(uint32(*sh), sh.GetUniformLocation(name), int32(len(values)), (*float32)(unsafe.Pointer(&values[0])))
+}
+
+func (sh *Shader) SetUniformMat4Array(name string, values [][16]float32) {
+	if len(values) == 0 {
+		return
+	}
+	gl.ProgramUniformMatrix4fv(uint32(*sh), sh.GetUniformLocation(name), int32(len(values)), false, &values[0][0])
+}
@@ -0,0 +1,307 @@
+package noor
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unsafe"
+
+	"github.com/go-gl/gl/v4.6-core/gl"
+)
+
+type AttributeType uint32
+
+const (
+	AttribFloat         AttributeType = gl.FLOAT
+	AttribHalfFloat     AttributeType = gl.HALF_FLOAT
+	AttribDouble        AttributeType = gl.DOUBLE
+	AttribByte          AttributeType = gl.BYTE
+	AttribUnsignedByte  AttributeType = gl.UNSIGNED_BYTE
+	AttribShort         AttributeType = gl.SHORT
+	AttribUnsignedShort AttributeType = gl.UNSIGNED_SHORT
+	AttribInt           AttributeType = gl.INT
+	AttribUnsignedInt   AttributeType = gl.UNSIGNED_INT
+)
+
+// VertexAttribute describes one input of the vertex shader.
+type VertexAttribute struct {
+	Name       string
+	Location   uint32
+	Components int32 // 1 to 4
+	Type       AttributeType
+	Normalized bool // integer data is mapped to 0..1 or -1..1 floats
+	Integer    bool // integer data is read as int/uint/ivecN/uvecN, not converted to float
+	Offset     int  // in bytes from the start of the vertex
+	Divisor    uint32
+}
+
+// VertexLayout describes how the attributes of a vertex are packed in a buffer.
+type VertexLayout struct {
+	Stride     int32 // size of a vertex in bytes
+	Attributes []VertexAttribute
+}
+
+// DefaultVertexLayout is the layout of Vertex, matching the inputs of the default shader.
+var DefaultVertexLayout = VertexLayout{
+	Stride: 44,
+	Attributes: []VertexAttribute{
+		{Name: "aPosition", Location: 0, Components: 3, Type: AttribFloat, Offset: 0},
+		{Name: "aColor", Location: 1, Components: 3, Type: AttribFloat, Offset: 12},
+		{Name: "aUv", Location: 2, Components: 2, Type: AttribFloat, Offset: 24},
+		{Name: "aNormal", Location: 3, Components: 3, Type: AttribFloat, Offset: 32},
+	},
+}
+
+// apply sets up the attributes on the bound vertex array, reading from the
+// bound GL_ARRAY_BUFFER.
+func (l VertexLayout) apply() {
+	for _, attr := range l.Attributes {
+		gl.EnableVertexAttribArray(attr.Location)
+		switch {
+		case attr.Type == AttribDouble:
+			gl.VertexAttribLPointerWithOffset(attr.Location, attr.Components, gl.DOUBLE, l.Stride, uintptr(attr.Offset))
+		case attr.Integer:
+			gl.VertexAttribIPointerWithOffset(attr.Location, attr.Components, uint32(attr.Type), l.Stride, uintptr(attr.Offset))
+		default:
+			gl.VertexAttribPointerWithOffset(attr.Location, attr.Components, uint32(attr.Type), attr.Normalized, l.Stride, uintptr(attr.Offset))
+		}
+		gl.VertexAttribDivisor(attr.Location, attr.Divisor)
+	}
+}
+
+// LayoutOf derives a layout from the exported fields of a vertex struct, with
+// locations assigned in field order starting at 0. Fields can be scalars,
+// arrays of 1 to 4 numbers, or structs of same-typed numbers like madar.Vector3.
+//
+// A `vertex` struct tag overrides the defaults:
+//
+//	Weights [4]uint8 `vertex:"aWeights,location=5,normalized"`
+//	Bones   [4]uint8 `vertex:"aBones,integer"`
+//	Debug   float32  `vertex:"-"`
+func LayoutOf[T any]() (VertexLayout, error) {
+	t := reflect.TypeFor[T]()
+	if t.Kind() != reflect.Struct {
+		return VertexLayout{}, fmt.Errorf("vertex type %s is not a struct", t)
+	}
+
+	layout := VertexLayout{Stride: int32(t.Size())}
+	location := uint32(0)
+	for i := range t.NumField() {
+		field := t.Field(i)
+		tag := field.Tag.Get("vertex")
+		if !field.IsExported() || tag == "-" {
+			continue
+		}
+
+		components, attrType, err := attributeFormat(field.Type)
+		if err != nil {
+			return VertexLayout{}, fmt.Errorf("vertex field %s: %w", field.Name, err)
+		}
+
+		attr := VertexAttribute{
+			Name:       "a" + field.Name,
+			Location:   location,
+			Components: components,
+			Type:       attrType,
+			Offset:     int(field.Offset),
+		}
+
+		options := strings.Split(tag, ",")
+		if options[0] != "" {
+			attr.Name = options[0]
+		}
+		for _, option := range options[1:] {
+			key, value, _ := strings.Cut(option, "=")
+			switch key {
+			case "location":
+				n, err := strconv.ParseUint(value, 10, 32)
+				if err != nil {
+					return VertexLayout{}, fmt.Errorf("vertex field %s: invalid location %q", field.Name, value)
+				}
+				attr.Location = uint32(n)
+			case "normalized":
+				attr.Normalized = true
+			case "integer":
+				attr.Integer = true
+			default:
+				return VertexLayout{}, fmt.Errorf("vertex field %s: unknown tag option %q", field.Name, option)
+			}
+		}
+
+		layout.Attributes = append(layout.Attributes, attr)
+		location = attr.Location + 1
+	}
+
+	return layout, nil
+}
+
+func attributeFormat(t reflect.Type) (int32, AttributeType, error) {
+	components := int32(1)
+	elem := t
+
+	switch t.Kind() {
+	case reflect.Array:
+		components = int32(t.Len())
+		elem = t.Elem()
+	case reflect.Struct:
+		components = int32(t.NumField())
+		if components > 0 {
+			elem = t.Field(0).Type
+		}
+		for i := range t.NumField() {
+			if t.Field(i).Type != elem {
+				return 0, 0, fmt.Errorf("struct %s mixes field types", t)
+			}
+		}
+	}
+
+	if components < 1 || components > 4 {
+		return 0, 0, fmt.Errorf("%s has %d components, want 1 to 4", t, components)
+	}
+
+	attrType, ok := attributeTypes[elem.Kind()]
+	if !ok {
+		return 0, 0, fmt.Errorf("unsupported component type %s", elem)
+	}
+	return components, attrType, nil
+}
+
+var attributeTypes = map[reflect.Kind]AttributeType{
+	reflect.Float32: AttribFloat,
+	reflect.Float64: AttribDouble,
+	reflect.Int8:    AttribByte,
+	reflect.Uint8:   AttribUnsignedByte,
+	reflect.Int16:   AttribShort,
+	reflect.Uint16:  AttribUnsignedShort,
+	reflect.Int32:   AttribInt,
+	reflect.Uint32:  AttribUnsignedInt,
+}
+
+// VertexStream is one attribute stored in its own buffer, for meshes built from
+// separate position, normal, uv... arrays.
+type VertexStream struct {
+	Attribute VertexAttribute // Offset is ignored
+	data      unsafe.Pointer
+	size      int
+	count     int
+}
+
+// Stream creates a stream for the attribute at location, with the component
+// type taken from T and components values per vertex, e.g.
+// Stream(4, 4, tangents) for a []float32 of xyzw tangents or
+// Stream(5, 4, weights) for a [][4]float32.
+func Stream[T any](location uint32, components int32, data []T) (VertexStream, error) {
+	elem := reflect.TypeFor[T]()
+	for elem.Kind() == reflect.Array {
+		elem = elem.Elem()
+	}
+	attrType, ok := attributeTypes[elem.Kind()]
+	if !ok {
+		return VertexStream{}, fmt.Errorf("unsupported stream type %s", elem)
+	}
+	if components < 1 || components > 4 {
+		return VertexStream{}, fmt.Errorf("stream has %d components, want 1 to 4", components)
+	}
+
+	size := len(data) * int(unsafe.Sizeof(*new(T)))
+	elemSize := int(elem.Size()) * int(components)
+
+	return VertexStream{
+		Attribute: VertexAttribute{Location: location, Components: components, Type: attrType},
+		data:      bufferPtr(data),
+		size:      size,
+		count:     size / elemSize,
+	}, nil
+}
+
+// NewMeshFromVertices creates a mesh from any vertex struct described by layout,
+// see LayoutOf. A zero layout Stride is taken as the size of T; other strides
+// must equal it.
+func NewMeshFromVertices[T any](vertices []T, indices []uint32, layout VertexLayout, drawMode DrawMode) *Mesh {
+	return NewMeshWithUsage(vertices, indices, layout, drawMode, StaticDraw)
+}
+
+// NewMeshWithUsage is NewMeshFromVertices for meshes that will be updated,
+// see SetVertices.
+func NewMeshWithUsage[T any](vertices []T, indices []uint32, layout VertexLayout, drawMode DrawMode, usage BufferUsage) *Mesh {
+	size := int(unsafe.Sizeof(*new(T)))
+	if layout.Stride == 0 {
+		layout.Stride = int32(size)
+	}
+	Assert(int(layout.Stride) == size, fmt.Sprintf("layout stride %d does not match the vertex size %d", layout.Stride, size))
+
+	m := newMeshWithUsage(indices, drawMode, usage)
+	m.Layout = layout
+
+	// sized from T, never from the layout, so the upload stays inside the slice
+	m.vertexCapacity = len(vertices) * size
+	gl.GenBuffers(1, &m.VBO)
+	gl.BindBuffer(gl.ARRAY_BUFFER, m.VBO)
+	gl.BufferData(gl.ARRAY_BUFFER, m.vertexCapacity, bufferPtr(vertices), uint32(usage))
+	layout.apply()
+
+	m.finish(int32(len(vertices)), indices)
+	return m
+}
+
+// NewMeshFromStreams creates a mesh with every attribute in its own buffer.
+// The vertex count is the length of the shortest stream.
+func NewMeshFromStreams(streams []VertexStream, indices []uint32, drawMode DrawMode) *Mesh {
+	m := newMesh(indices, drawMode)
+
+	count := -1
+	for _, stream := range streams {
+		var vbo uint32
+		gl.GenBuffers(1, &vbo)
+		gl.BindBuffer(gl.ARRAY_BUFFER, vbo)
+		gl.BufferData(gl.ARRAY_BUFFER, stream.size, stream.data, gl.STATIC_DRAW)
+
+		attr := stream.Attribute
+		attr.Offset = 0
+		VertexLayout{Attributes: []VertexAttribute{attr}}.apply()
+
+		m.Layout.Attributes = append(m.Layout.Attributes, attr)
+		m.streams = append(m.streams, vbo)
+		if count < 0 || stream.count < count {
+			count = stream.count
+		}
+	}
+	if len(m.streams) > 0 {
+		m.VBO = m.streams[0]
+	}
+
+	m.finish(int32(max(count, 0)), indices)
+	return m
+}
+
+// newMesh creates the vertex array and the index buffer, leaving the vertex array bound.
+func newMesh(indices []uint32, drawMode DrawMode) *Mesh {
+	return newMeshWithUsage(indices, drawMode, StaticDraw)
+}
+
+func newMeshWithUsage(indices []uint32, drawMode DrawMode, usage BufferUsage) *Mesh {
+	m := &Mesh{DrawMode: drawMode, Usage: usage}
+	gl.GenVertexArrays(1, &m.VAO)
+	gl.BindVertexArray(m.VAO)
+
+	if len(indices) > 0 {
+		m.indexCapacity = len(indices) * 4
+		gl.GenBuffers(1, &m.EBO)
+		gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, m.EBO)
+		gl.BufferData(gl.ELEMENT_ARRAY_BUFFER, m.indexCapacity, gl.Ptr(indices), uint32(usage))
+	}
+	return m
+}
+
+// finish sets the draw count and unbinds the vertex array.
+func (m *Mesh) finish(vertexCount int32, indices []uint32) {
+	m.Count = vertexCount
+	if len(indices) > 0 {
+		m.Count = int32(len(indices))
+		m.DrawElements = true
+	}
+
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+	gl.BindVertexArray(0)
+}
@@ -0,0 +1,121 @@
+package noor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ahmedsat/madar"
+)
+
+func TestLayoutOfVertex(t *testing.T) {
+	layout, err := LayoutOf[Vertex]()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if layout.Stride != DefaultVertexLayout.Stride || len(layout.Attributes) != len(DefaultVertexLayout.Attributes) {
+		t.Fatalf("got %+v, want the shape of DefaultVertexLayout", layout)
+	}
+	for i, attr := range layout.Attributes {
+		want := DefaultVertexLayout.Attributes[i]
+		if attr.Location != want.Location || attr.Components != want.Components || attr.Type != want.Type || attr.Offset != want.Offset {
+			t.Errorf("attribute %d is %+v, want %+v", i, attr, want)
+		}
+	}
+}
+
+func TestLayoutOfTags(t *testing.T) {
+	type skinned struct {
+		Position madar.Vector3
+		hidden   float32
+		Debug    float32  `vertex:"-"`
+		Weights  [4]uint8 `vertex:"aWeights,location=5,normalized"`
+		Bones    [4]uint8 `vertex:",integer"`
+		Scale    float64
+	}
+
+	layout, err := LayoutOf[skinned]()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []VertexAttribute{
+		{Name: "aPosition", Location: 0, Components: 3, Type: AttribFloat, Offset: 0},
+		{Name: "aWeights", Location: 5, Components: 4, Type: AttribUnsignedByte, Normalized: true, Offset: 20},
+		{Name: "aBones", Location: 6, Components: 4, Type: AttribUnsignedByte, Integer: true, Offset: 24},
+		{Name: "aScale", Location: 7, Components: 1, Type: AttribDouble, Offset: 32},
+	}
+	if layout.Stride != 40 {
+		t.Errorf("stride is %d, want 40", layout.Stride)
+	}
+	if len(layout.Attributes) != len(want) {
+		t.Fatalf("got attributes %+v, want %+v", layout.Attributes, want)
+	}
+	for i := range want {
+		if layout.Attributes[i] != want[i] {
+			t.Errorf("attribute %d is %+v, want %+v", i, layout.Attributes[i], want[i])
+		}
+	}
+}
+
+func TestLayoutOfErrors(t *testing.T) {
+	type tooWide struct{ Data [5]float32 }
+	type mixed struct {
+		Data struct {
+			A, B float32
+			C    int32
+		}
+	}
+	type unsupported struct{ Name string }
+	type badTag struct {
+		Data float32 `vertex:"aData,packed"`
+	}
+	type badLocation struct {
+		Data float32 `vertex:"aData,location=x"`
+	}
+
+	tests := []struct {
+		name   string
+		layout func() (VertexLayout, error)
+		want   string
+	}{
+		{"not a struct", LayoutOf[float32], "is not a struct"},
+		{"too many components", LayoutOf[tooWide], "has 5 components"},
+		{"mixed struct", LayoutOf[mixed], "mixes field types"},
+		{"unsupported type", LayoutOf[unsupported], "unsupported component type string"},
+		{"unknown option", LayoutOf[badTag], `unknown tag option "packed"`},
+		{"bad location", LayoutOf[badLocation], `invalid location "x"`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := test.layout()
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("got error %v, want %q", err, test.want)
+			}
+		})
+	}
+}
+
+func TestStream(t *testing.T) {
+	flat, err := Stream(4, 4, make([]float32, 8))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if flat.count != 2 || flat.size != 32 || flat.Attribute.Type != AttribFloat || flat.Attribute.Location != 4 {
+		t.Errorf("a []float32 of 8 in 4 components is %+v", flat)
+	}
+
+	arrays, err := Stream(5, 4, make([][4]uint16, 3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if arrays.count != 3 || arrays.size != 24 || arrays.Attribute.Type != AttribUnsignedShort {
+		t.Errorf("a [][4]uint16 of 3 is %+v", arrays)
+	}
+
+	if _, err := Stream(0, 3, []string{"x"}); err == nil {
+		t.Error("a string stream was accepted")
+	}
+	if _, err := Stream(0, 5, make([]float32, 5)); err == nil {
+		t.Error("a stream of 5 components was accepted")
+	}
+}